@@ -3,8 +3,10 @@
 package api
 
 import (
+	"errors"
 	"financialapi/internal/financials"
 	"financialapi/internal/goalseek"
+	"financialapi/internal/montecarlo"
 	"financialapi/internal/runout"
 	"net/http"
 
@@ -26,7 +28,7 @@ func (s *Server) GoalSeekHandler(c *gin.Context) {
 	}
 
 	if err := engine.Compute(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(solverErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -34,6 +36,49 @@ func (s *Server) GoalSeekHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// solverErrorStatus reports a goal seek without a solution as 422, since the
+// request was valid but its target cannot be reached, and any other failure
+// as 500.
+func solverErrorStatus(err error) int {
+	if errors.Is(err, financials.ErrNoSolution) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) MonteCarloHandler(c *gin.Context) {
+	var params montecarlo.MonteCarloParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	engine := montecarlo.NewMonteCarloCalculator(params)
+
+	if err := engine.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := engine.Compute(); err != nil {
+		c.JSON(simulationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := engine.GetResult()
+	c.JSON(http.StatusOK, result)
+}
+
+// simulationErrorStatus reports distributions that draw no valid scenario as
+// 422, since the request was valid but cannot be simulated, and any other
+// failure as 500.
+func simulationErrorStatus(err error) int {
+	if errors.Is(err, montecarlo.ErrNoValidScenario) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
 func (s *Server) RunoutHandler(c *gin.Context) {
 	var params runout.RunoutParams
 	if err := c.ShouldBindJSON(&params); err != nil {
@@ -55,4 +100,50 @@ func (s *Server) RunoutHandler(c *gin.Context) {
 
 	result := engine.GetResult()
 	c.JSON(http.StatusOK, result)
-}
\ No newline at end of file
+}
+
+func (s *Server) RunoutGoalSeekHandler(c *gin.Context) {
+	var params runout.GoalSeekParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	engine := runout.NewGoalSeekCalculator(params)
+
+	if err := engine.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := engine.Compute(); err != nil {
+		c.JSON(solverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := engine.GetResult()
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *Server) RunoutScenarioHandler(c *gin.Context) {
+	var params runout.ScenarioParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	engine := runout.NewScenarioCalculator(params)
+
+	if err := engine.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := engine.Compute(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := engine.GetResult()
+	c.JSON(http.StatusOK, result)
+}
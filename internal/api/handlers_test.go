@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"financialapi/internal/financials"
@@ -65,3 +66,253 @@ func TestGoalSeekHandler(t *testing.T) {
 	_, exists = response["finalCumulativeProfit"]
 	testutils.AssertEqual(t, true, exists)
 }
+
+func TestGoalSeekHandlerNoSolution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	server := &Server{router: router}
+	server.setupRoutes()
+
+	// Without shop visit costs the profit does not depend on initialTSN, so
+	// no value of it reaches the target.
+	params := financials.FinancialParams{
+		NumYears:     10,
+		AuHours:      450,
+		HSITSN:       1000,
+		OverhaulTSN:  3000,
+		TargetProfit: 3000000,
+		InitialRate:  320,
+		InitialTSN:   100,
+		SolveFor:     "initialTSN",
+	}
+	jsonParams, _ := json.Marshal(params)
+
+	req, _ := http.NewRequest("POST", "/goalseek", bytes.NewBuffer(jsonParams))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if !strings.HasPrefix(response["error"], "no solution in range") {
+		t.Errorf("Expected a no solution error, got %q", response["error"])
+	}
+
+	// Reaching the target at this rate would need a negative overhaul cost.
+	params.SolveFor, params.HSICost, params.OverhaulCost = "overhaulCost", 50000, 100000
+	jsonParams, _ = json.Marshal(params)
+
+	req, _ = http.NewRequest("POST", "/goalseek", bytes.NewBuffer(jsonParams))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestMonteCarloHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	server := &Server{router: router}
+	server.setupRoutes()
+
+	body := []byte(`{
+		"params": {
+			"numYears": 10, "auHours": 450, "initialTSN": 100, "rateEscalation": 5, "aic": 10,
+			"hsitsn": 1000, "overhaulTSN": 3000, "hsiCost": 50000, "overhaulCost": 100000, "initialRate": 320
+		},
+		"scenarios": 500,
+		"seed": 1,
+		"distributions": [{"field": "auHours", "type": "normal", "mean": 450, "stdDev": 50}]
+	}`)
+
+	req, _ := http.NewRequest("POST", "/montecarlo", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	for _, key := range []string{"mean", "stdDev", "p10", "p50", "p90", "probabilityOfLoss", "histogram"} {
+		_, exists := response[key]
+		testutils.AssertEqual(t, true, exists)
+	}
+
+	// Every draw of a negative AuHours is invalid, so no scenario can be run.
+	body = []byte(`{
+		"params": {
+			"numYears": 10, "auHours": 450, "initialTSN": 100, "rateEscalation": 5, "aic": 10,
+			"hsitsn": 1000, "overhaulTSN": 3000, "hsiCost": 50000, "overhaulCost": 100000, "initialRate": 320
+		},
+		"scenarios": 500,
+		"seed": 1,
+		"distributions": [{"field": "auHours", "type": "uniform", "min": -20, "max": -10}]
+	}`)
+
+	req, _ = http.NewRequest("POST", "/montecarlo", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestRunoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	server := &Server{router: router}
+	server.setupRoutes()
+
+	// Dates may be plain dates or RFC 3339 timestamps.
+	body := []byte(`{
+		"contractStartDate": "2023-01-01",
+		"contractEndDate": "2034-12-31T23:59:59Z",
+		"auHours": 480, "warrantyRate": 243.6, "firstRunRate": 255.13, "secondRunRate": 255.13, "thirdRunRate": 255.13,
+		"managementFees": 15, "aicFees": 20, "trustLoadFees": 2.98, "buyIn": 1352291, "rateEscalation": 8.75,
+		"flightHoursMinimum": 150, "numOfDaysInYear": 365, "numOfDaysInMonth": 30, "enrollmentFees": 25000,
+		"numEngines": 1,
+		"engineParams": [{
+			"serialNumber": "1085718", "position": 1,
+			"warrantyExpDate": "2025-10-31", "warrantyExpHours": 1000,
+			"firstRunRateSwitchDate": "2026-11-01", "secondRunRateSwitchDate": "2027-05-01", "thirdRunRateSwitchDate": "2028-07-01"
+		}]
+	}`)
+
+	req, _ := http.NewRequest("POST", "/runout", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Periods []struct {
+			StartDate string
+			EndDate   string
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	testutils.AssertEqual(t, 12, len(response.Periods))
+	if len(response.Periods) > 0 {
+		testutils.AssertEqual(t, "2023-01-01", response.Periods[0].StartDate)
+		testutils.AssertEqual(t, "2034-12-31", response.Periods[len(response.Periods)-1].EndDate)
+	}
+}
+
+func TestRunoutGoalSeekHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	server := &Server{router: router}
+	server.setupRoutes()
+
+	body := []byte(`{
+		"params": {
+			"contractStartDate": "2023-01-01", "contractEndDate": "2034-12-31",
+			"auHours": 480, "warrantyRate": 243.6, "firstRunRate": 255.13, "secondRunRate": 255.13, "thirdRunRate": 255.13,
+			"managementFees": 15, "aicFees": 20, "trustLoadFees": 2.98, "buyIn": 1352291, "rateEscalation": 8.75,
+			"flightHoursMinimum": 150, "numOfDaysInYear": 365, "numOfDaysInMonth": 30, "enrollmentFees": 25000,
+			"numEngines": 1,
+			"engineParams": [{
+				"serialNumber": "1085718", "position": 1,
+				"warrantyExpDate": "2025-10-31", "warrantyExpHours": 1000,
+				"firstRunRateSwitchDate": "2026-11-01", "secondRunRateSwitchDate": "2027-05-01", "thirdRunRateSwitchDate": "2028-07-01"
+			}]
+		},
+		"solveFor": "rateMultiplier",
+		"target": 5000000
+	}`)
+
+	req, _ := http.NewRequest("POST", "/runout/goalseek", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response struct {
+		SolveFor    string
+		SolvedValue float64
+		Result      struct {
+			CumulativeTotalRevenue float64
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	testutils.AssertEqual(t, "rateMultiplier", response.SolveFor)
+	if response.Result.CumulativeTotalRevenue < 4999999.99 || response.Result.CumulativeTotalRevenue > 5000000.01 {
+		t.Errorf("Expected cumulative revenue near 5000000, got %f", response.Result.CumulativeTotalRevenue)
+	}
+
+	// The warranty years alone bill more than the target, so only a negative
+	// run rate would reach it.
+	body = bytes.Replace(body, []byte(`"solveFor": "rateMultiplier",
+		"target": 5000000`), []byte(`"solveFor": "runRate",
+		"target": 100000`), 1)
+
+	req, _ = http.NewRequest("POST", "/runout/goalseek", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestRunoutScenarioHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	server := &Server{router: router}
+	server.setupRoutes()
+
+	body := []byte(`{
+		"params": {
+			"contractStartDate": "2023-01-01", "contractEndDate": "2034-12-31",
+			"auHours": 480, "warrantyRate": 243.6, "firstRunRate": 255.13, "secondRunRate": 255.13, "thirdRunRate": 255.13,
+			"managementFees": 15, "aicFees": 20, "trustLoadFees": 2.98, "buyIn": 1352291, "rateEscalation": 8.75,
+			"flightHoursMinimum": 150, "numOfDaysInYear": 365, "numOfDaysInMonth": 30, "enrollmentFees": 25000,
+			"numEngines": 1,
+			"engineParams": [{
+				"serialNumber": "1085718", "position": 1,
+				"warrantyExpDate": "2025-10-31", "warrantyExpHours": 1000,
+				"firstRunRateSwitchDate": "2026-11-01", "secondRunRateSwitchDate": "2027-05-01", "thirdRunRateSwitchDate": "2028-07-01"
+			}]
+		},
+		"termination": {"date": "2029-01-01", "feeType": "flat", "amount": 50000}
+	}`)
+
+	req, _ := http.NewRequest("POST", "/runout/scenario", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutils.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Base           struct{ Periods []struct{} }
+		Modified       struct{ Periods []struct{} }
+		TerminationFee float64
+		Deltas         []struct {
+			ContractYearNumber int
+			TotalRevenue       float64
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	testutils.AssertEqual(t, 12, len(response.Base.Periods))
+	testutils.AssertEqual(t, 6, len(response.Modified.Periods))
+	testutils.AssertEqual(t, 12, len(response.Deltas))
+	testutils.AssertEqual(t, 50000.0, response.TerminationFee)
+	if len(response.Deltas) == 12 && response.Deltas[11].TotalRevenue >= 0 {
+		t.Errorf("Expected the terminated years to lose revenue, got %f", response.Deltas[11].TotalRevenue)
+	}
+}
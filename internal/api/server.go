@@ -19,6 +19,9 @@ func NewServer() *Server {
 func (s *Server) setupRoutes() {
 	s.router.POST("/goalseek", s.GoalSeekHandler)
 	s.router.POST("/runout", s.RunoutHandler)
+	s.router.POST("/runout/goalseek", s.RunoutGoalSeekHandler)
+	s.router.POST("/runout/scenario", s.RunoutScenarioHandler)
+	s.router.POST("/montecarlo", s.MonteCarloHandler)
 }
 
 func (s *Server) Run(addr string) error {
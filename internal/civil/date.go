@@ -0,0 +1,135 @@
+// Package civil provides a calendar date without a time of day or location,
+// so that date arithmetic always runs on whole days.
+package civil
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date is a calendar date. The zero value is not a valid date; IsZero
+// reports it.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// New returns the date for the given year, month and day, normalising
+// values out of range the way time.Date does, so New(2024, 3, 0) is
+// 29 February 2024.
+func New(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// DateOf returns the calendar date of t in its own location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// Parse accepts a date as "YYYY-MM-DD" or as an RFC 3339 timestamp, whose
+// date in its own offset is used.
+func Parse(s string) (Date, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return DateOf(t), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return DateOf(t), nil
+	}
+	return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", s)
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// IsZero reports whether d is the zero Date.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// In returns midnight at the start of d in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns the date n days after d.
+func (d Date) AddDays(n int) Date {
+	return New(d.Year, d.Month, d.Day+n)
+}
+
+// AddDate adds years, months and days to d, normalising like
+// time.Time.AddDate.
+func (d Date) AddDate(years, months, days int) Date {
+	return New(d.Year+years, d.Month+time.Month(months), d.Day+days)
+}
+
+// DaysSince returns the number of days from other to d, negative when d is
+// earlier.
+func (d Date) DaysSince(other Date) int {
+	return int(d.In(time.UTC).Sub(other.In(time.UTC)).Hours() / 24)
+}
+
+// Before reports whether d is earlier than other.
+func (d Date) Before(other Date) bool {
+	return d.Compare(other) < 0
+}
+
+// After reports whether d is later than other.
+func (d Date) After(other Date) bool {
+	return d.Compare(other) > 0
+}
+
+// Compare returns -1, 0 or 1 as d is earlier than, equal to or later than
+// other.
+func (d Date) Compare(other Date) int {
+	switch {
+	case d.Year != other.Year:
+		return compare(d.Year, other.Year)
+	case d.Month != other.Month:
+		return compare(int(d.Month), int(other.Month))
+	}
+	return compare(d.Day, other.Day)
+}
+
+// MarshalText encodes d as "YYYY-MM-DD".
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText accepts the formats of Parse.
+func (d *Date) UnmarshalText(data []byte) error {
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Min returns the earlier of a and b.
+func Min(a, b Date) Date {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// Max returns the later of a and b.
+func Max(a, b Date) Date {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func compare(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+// File: internal/civil/date_test.go
+
+package civil
+
+import (
+	"encoding/json"
+	"financialapi/pkg/testutils"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Date
+	}{
+		{"2024-02-29", New(2024, 2, 29)},
+		{"2022-01-14T00:00:00Z", New(2022, 1, 14)},
+		{"2034-02-14T23:59:59Z", New(2034, 2, 14)},
+		// The date is taken in the timestamp's own offset.
+		{"2025-10-31T23:59:59-05:00", New(2025, 10, 31)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		testutils.AssertNoError(t, err)
+		testutils.AssertEqual(t, tt.want, got)
+	}
+
+	for _, input := range []string{"", "2023-02-29", "14/01/2022", "2022-01-14 00:00"} {
+		_, err := Parse(input)
+		testutils.AssertError(t, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Start Date `json:"start"`
+		End   Date `json:"end"`
+	}
+	err := json.Unmarshal([]byte(`{"start": "2022-01-14", "end": "2034-02-14T23:59:59Z"}`), &v)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, New(2022, 1, 14), v.Start)
+	testutils.AssertEqual(t, New(2034, 2, 14), v.End)
+
+	data, err := json.Marshal(v)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, `{"start":"2022-01-14","end":"2034-02-14"}`, string(data))
+
+	testutils.AssertError(t, json.Unmarshal([]byte(`{"start": "January 14"}`), &v))
+}
+
+func TestArithmetic(t *testing.T) {
+	testutils.AssertEqual(t, New(2024, 3, 1), New(2024, 2, 28).AddDays(2))
+	testutils.AssertEqual(t, New(2024, 2, 29), New(2024, 3, 0))
+	testutils.AssertEqual(t, New(2023, 1, 1), New(2023, 12, 31).AddDate(-1, 0, 1))
+	testutils.AssertEqual(t, New(2025, 3, 1), New(2024, 2, 29).AddDate(1, 0, 0))
+
+	testutils.AssertEqual(t, 366, New(2025, 1, 1).DaysSince(New(2024, 1, 1)))
+	testutils.AssertEqual(t, -1, New(2024, 2, 28).DaysSince(New(2024, 2, 29)))
+
+	// Whole days whatever the daylight saving changes in a location.
+	ny, err := time.LoadLocation("America/New_York")
+	if err == nil {
+		testutils.AssertEqual(t, New(2024, 3, 10), DateOf(time.Date(2024, 3, 10, 23, 30, 0, 0, ny)))
+	}
+	testutils.AssertEqual(t, 1, New(2024, 3, 11).DaysSince(New(2024, 3, 10)))
+}
+
+func TestCompare(t *testing.T) {
+	a, b := New(2024, 2, 29), New(2024, 3, 1)
+	testutils.AssertEqual(t, true, a.Before(b))
+	testutils.AssertEqual(t, false, a.After(b))
+	testutils.AssertEqual(t, 0, a.Compare(New(2024, 2, 29)))
+	testutils.AssertEqual(t, a, Min(a, b))
+	testutils.AssertEqual(t, b, Max(a, b))
+	testutils.AssertEqual(t, true, Date{}.IsZero())
+	testutils.AssertEqual(t, "2024-02-29", a.String())
+}
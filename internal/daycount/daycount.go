@@ -0,0 +1,87 @@
+// Package daycount counts the days between dates and converts them to year
+// fractions under the usual market day-count conventions.
+package daycount
+
+import (
+	"financialapi/internal/civil"
+	"fmt"
+)
+
+// Convention names a day-count convention.
+type Convention string
+
+// Supported conventions.
+const (
+	// Act365Fixed counts actual days over a 365 day year.
+	Act365Fixed Convention = "ACT/365F"
+	// Act360 counts actual days over a 360 day year.
+	Act360 Convention = "ACT/360"
+	// Thirty360 counts every month as 30 days over a 360 day year, using
+	// the bond basis rule for the 31st of a month.
+	Thirty360 Convention = "30/360"
+	// ActAct counts the actual days in each calendar year over the length
+	// of that year, 365 or 366 (ISDA).
+	ActAct Convention = "ACT/ACT"
+)
+
+func (c Convention) Validate() error {
+	switch c {
+	case Act365Fixed, Act360, Thirty360, ActAct:
+		return nil
+	}
+	return fmt.Errorf("unknown day-count convention %q, must be %q, %q, %q or %q", string(c), Act365Fixed, Act360, Thirty360, ActAct)
+}
+
+// Days counts the days from start up to, but not including, end.
+func (c Convention) Days(start, end civil.Date) int {
+	if c == Thirty360 {
+		return days360(start, end)
+	}
+	return actualDays(start, end)
+}
+
+// YearFraction returns the length of the period from start up to, but not
+// including, end in years.
+func (c Convention) YearFraction(start, end civil.Date) float64 {
+	switch c {
+	case Act360:
+		return float64(actualDays(start, end)) / 360
+	case Thirty360:
+		return float64(days360(start, end)) / 360
+	case ActAct:
+		if end.Before(start) {
+			return -c.YearFraction(end, start)
+		}
+		fraction := 0.0
+		for year := start.Year; year <= end.Year; year++ {
+			from := civil.Max(start, civil.New(year, 1, 1))
+			to := civil.Min(end, civil.New(year+1, 1, 1))
+			fraction += float64(actualDays(from, to)) / float64(DaysInYear(year))
+		}
+		return fraction
+	}
+	return float64(actualDays(start, end)) / 365
+}
+
+// DaysInYear returns 366 for leap years and 365 otherwise.
+func DaysInYear(year int) int {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
+
+func actualDays(start, end civil.Date) int {
+	return end.DaysSince(start)
+}
+
+func days360(start, end civil.Date) int {
+	d1, d2 := start.Day, end.Day
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return 360*(end.Year-start.Year) + 30*(int(end.Month)-int(start.Month)) + (d2 - d1)
+}
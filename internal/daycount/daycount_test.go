@@ -0,0 +1,99 @@
+// File: internal/daycount/daycount_test.go
+
+package daycount
+
+import (
+	"financialapi/internal/civil"
+	"financialapi/pkg/testutils"
+	"math"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) civil.Date {
+	return civil.New(year, month, d)
+}
+
+func TestDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		convention Convention
+		start, end civil.Date
+		days       int
+	}{
+		{"actual leap year", Act365Fixed, day(2024, 1, 1), day(2025, 1, 1), 366},
+		{"actual across 29 February", Act360, day(2024, 2, 28), day(2024, 3, 1), 2},
+		{"actual within a month", ActAct, day(2023, 1, 1), day(2023, 1, 31), 30},
+		{"30/360 full year", Thirty360, day(2024, 1, 1), day(2025, 1, 1), 360},
+		{"30/360 February", Thirty360, day(2024, 2, 1), day(2024, 3, 1), 30},
+		{"30/360 from the 31st", Thirty360, day(2023, 1, 31), day(2023, 3, 31), 60},
+		{"30/360 to the 31st", Thirty360, day(2023, 1, 15), day(2023, 3, 31), 76},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.AssertEqual(t, tt.days, tt.convention.Days(tt.start, tt.end))
+		})
+	}
+}
+
+func TestYearFraction(t *testing.T) {
+	tests := []struct {
+		name       string
+		convention Convention
+		start, end civil.Date
+		fraction   float64
+	}{
+		{"ACT/365F leap year", Act365Fixed, day(2024, 1, 1), day(2025, 1, 1), 366.0 / 365},
+		{"ACT/360 leap year", Act360, day(2024, 1, 1), day(2025, 1, 1), 366.0 / 360},
+		{"30/360 leap year", Thirty360, day(2024, 1, 1), day(2025, 1, 1), 1},
+		{"ACT/ACT leap year", ActAct, day(2024, 1, 1), day(2025, 1, 1), 1},
+		{"ACT/ACT common year", ActAct, day(2023, 1, 1), day(2024, 1, 1), 1},
+		{"ACT/ACT across years", ActAct, day(2023, 7, 1), day(2024, 7, 1), 184.0/365 + 182.0/366},
+		{"ACT/ACT several years", ActAct, day(2022, 1, 14), day(2025, 1, 14), 352.0/365 + 1 + 1 + 13.0/365},
+		{"ACT/ACT reversed", ActAct, day(2024, 7, 1), day(2023, 7, 1), -(184.0/365 + 182.0/366)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.convention.YearFraction(tt.start, tt.end)
+			if math.Abs(got-tt.fraction) > 1e-12 {
+				t.Errorf("Expected year fraction %.12f, got %.12f", tt.fraction, got)
+			}
+		})
+	}
+}
+
+func TestYearFractionAdditive(t *testing.T) {
+	// Splitting a period at the first of each month leaves its year fraction unchanged.
+	for _, convention := range []Convention{Act365Fixed, Act360, Thirty360, ActAct} {
+		start, end := day(2023, 11, 14), day(2025, 3, 10)
+		whole := convention.YearFraction(start, end)
+
+		sum := 0.0
+		from := start
+		for from.Before(end) {
+			to := civil.New(from.Year, from.Month+1, 1)
+			if to.After(end) {
+				to = end
+			}
+			sum += convention.YearFraction(from, to)
+			from = to
+		}
+		if math.Abs(sum-whole) > 1e-12 {
+			t.Errorf("%s: months sum to %.12f, the whole period is %.12f", convention, sum, whole)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testutils.AssertNoError(t, ActAct.Validate())
+	testutils.AssertError(t, Convention("ACT/364").Validate())
+}
+
+func TestDaysInYear(t *testing.T) {
+	testutils.AssertEqual(t, 366, DaysInYear(2024))
+	testutils.AssertEqual(t, 365, DaysInYear(2023))
+	testutils.AssertEqual(t, 365, DaysInYear(1900))
+	testutils.AssertEqual(t, 366, DaysInYear(2000))
+}
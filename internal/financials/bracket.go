@@ -0,0 +1,149 @@
+package financials
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoSolution is returned, wrapped with the details, when no valid value
+// reaches the target: the objective does not change sign on any interval the
+// bracketing solvers try, or the value that reaches it is out of range.
+var ErrNoSolution = errors.New("no solution")
+
+const (
+	// bracketGrowth is the factor by which ExpandBracket widens the search
+	// interval on each attempt.
+	bracketGrowth = 1.6
+	// epsilon is the float64 machine epsilon.
+	epsilon = 2.220446049250313e-16
+)
+
+// ExpandBracket searches outward from x0 for an interval [a, b] on which f
+// changes sign. The interval starts at x0 ± 10% (or ± 1 when x0 is zero) and
+// the end with the smaller |f| is pushed out geometrically until a sign change
+// is found or maxExpand attempts have been made.
+func ExpandBracket(f func(float64) (float64, error), x0 float64, maxExpand int) (float64, float64, error) {
+	step := math.Abs(x0) * 0.1
+	if step == 0 {
+		step = 1
+	}
+	a, b := x0-step, x0+step
+
+	fa, err := f(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	fb, err := f(b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := 0; i < maxExpand; i++ {
+		if math.IsNaN(fa) || math.IsNaN(fb) {
+			return 0, 0, fmt.Errorf("objective is undefined in range [%g, %g]", a, b)
+		}
+		if fa == 0 || fb == 0 || math.Signbit(fa) != math.Signbit(fb) {
+			return a, b, nil
+		}
+
+		if math.Abs(fa) < math.Abs(fb) {
+			a += bracketGrowth * (a - b)
+			if fa, err = f(a); err != nil {
+				return 0, 0, err
+			}
+		} else {
+			b += bracketGrowth * (b - a)
+			if fb, err = f(b); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("%w in range [%g, %g]", ErrNoSolution, a, b)
+}
+
+// Brent finds a root of f inside [a, b] with Brent's method, which combines
+// bisection with secant and inverse quadratic interpolation steps. f(a) and
+// f(b) must have opposite signs. Like NewtonRaphson, it stops once |f(x)| is
+// below tol; it also stops when the bracket has shrunk to machine precision.
+func Brent(f func(float64) (float64, error), a, b, tol float64, maxIter int) (float64, int, error) {
+	fa, err := f(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	fb, err := f(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if fa == 0 {
+		return a, 0, nil
+	}
+	if fb == 0 {
+		return b, 0, nil
+	}
+	if math.Signbit(fa) == math.Signbit(fb) {
+		return 0, 0, fmt.Errorf("%w in range [%g, %g]", ErrNoSolution, a, b)
+	}
+
+	c, fc := b, fb
+	var d, e float64
+	for i := 0; i < maxIter; i++ {
+		if math.Signbit(fb) == math.Signbit(fc) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+
+		xtol := 2*epsilon*math.Abs(b) + math.SmallestNonzeroFloat64
+		m := 0.5 * (c - b)
+		if math.Abs(fb) < tol || math.Abs(m) <= xtol {
+			return b, i + 1, nil
+		}
+
+		if math.Abs(e) >= xtol && math.Abs(fa) > math.Abs(fb) {
+			// Attempt interpolation: secant when only two points are
+			// distinct, inverse quadratic otherwise.
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				p = 2 * m * s
+				q = 1 - s
+			} else {
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*m*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			}
+			p = math.Abs(p)
+
+			if 2*p < math.Min(3*m*q-math.Abs(xtol*q), math.Abs(e*q)) {
+				e = d
+				d = p / q
+			} else {
+				d = m
+				e = d
+			}
+		} else {
+			d = m
+			e = d
+		}
+
+		a, fa = b, fb
+		if math.Abs(d) > xtol {
+			b += d
+		} else {
+			b += math.Copysign(xtol, m)
+		}
+		if fb, err = f(b); err != nil {
+			return 0, i + 1, err
+		}
+	}
+	return 0, maxIter, fmt.Errorf("Brent's method did not converge within %d iterations", maxIter)
+}
@@ -0,0 +1,73 @@
+// File: internal/financials/bracket_test.go
+
+package financials
+
+import (
+	"financialapi/pkg/testutils"
+	"math"
+	"testing"
+)
+
+func TestBrent(t *testing.T) {
+	f := func(x float64) (float64, error) {
+		return x*x*x - 2*x - 5, nil
+	}
+
+	root, iterations, err := Brent(f, 2, 3, 1e-12, 100)
+	testutils.AssertNoError(t, err)
+
+	if math.Abs(root-2.0945514815423265) > 1e-10 {
+		t.Errorf("Expected root 2.0945514815423265, got %.16f", root)
+	}
+	if iterations <= 0 {
+		t.Errorf("Expected positive number of iterations, got %d", iterations)
+	}
+}
+
+func TestBrentKinkedObjective(t *testing.T) {
+	// A step in the objective, like an HSI cost landing in a different year,
+	// leaves Newton-Raphson with a zero derivative on the flat parts.
+	f := func(x float64) (float64, error) {
+		if x < 10 {
+			return -100, nil
+		}
+		return x - 5, nil
+	}
+
+	_, _, err := NewtonRaphson(f, func(float64) (float64, error) { return 0, nil }, 1, 1e-8, 100)
+	testutils.AssertError(t, err)
+
+	a, b, err := ExpandBracket(f, 1, 50)
+	testutils.AssertNoError(t, err)
+
+	root, _, err := Brent(f, a, b, 1e-8, 100)
+	testutils.AssertNoError(t, err)
+
+	// There is no exact root; Brent converges onto the discontinuity.
+	if math.Abs(root-10) > 1e-9 {
+		t.Errorf("Expected root at the step (10), got %f", root)
+	}
+}
+
+func TestExpandBracketNoSolution(t *testing.T) {
+	f := func(x float64) (float64, error) {
+		return x*x + 1, nil
+	}
+
+	_, _, err := ExpandBracket(f, 5, 50)
+	testutils.AssertError(t, err)
+}
+
+func TestGoalSeekPoorInitialGuess(t *testing.T) {
+	params := getTestParams()
+	params.InitialRate = 1e-9
+
+	rate, _, err := GoalSeek(params.TargetProfit, params, params.InitialRate)
+	testutils.AssertNoError(t, err)
+
+	profit, err := CalculateFinancials(rate, params)
+	testutils.AssertNoError(t, err)
+	if math.Abs(profit-params.TargetProfit) > 1e-6 {
+		t.Errorf("Expected profit %f at solved rate, got %f", params.TargetProfit, profit)
+	}
+}
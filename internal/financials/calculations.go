@@ -5,37 +5,124 @@ import (
 	"math"
 )
 
+// YearlyCashFlow is one contract year of the profit model.
+type YearlyCashFlow struct {
+	Year          int     `json:"year"`
+	Hours         float64 `json:"hours"`
+	TSN           float64 `json:"tsn"`
+	EscalatedRate float64 `json:"escalatedRate"`
+	EngineRevenue float64 `json:"engineRevenue"`
+	AICRevenue    float64 `json:"aicRevenue"`
+	TotalRevenue  float64 `json:"totalRevenue"`
+	HSICount      int     `json:"hsiCount"`
+	OverhaulCount int     `json:"overhaulCount"`
+
+	// Nominal costs are in CostBaseYear money; HSICost and OverhaulCost
+	// carry the cost escalation for the year.
+	NominalHSICost      float64 `json:"nominalHsiCost"`
+	NominalOverhaulCost float64 `json:"nominalOverhaulCost"`
+	HSICost             float64 `json:"hsiCost"`
+	OverhaulCost        float64 `json:"overhaulCost"`
+	TotalCost           float64 `json:"totalCost"`
+	Profit              float64 `json:"profit"`
+	CumulativeProfit    float64 `json:"cumulativeProfit"`
+
+	DiscountedProfit           float64 `json:"discountedProfit"`
+	CumulativeDiscountedProfit float64 `json:"cumulativeDiscountedProfit"`
+}
+
+// CalculateFinancials returns the cumulative profit over the contract at the
+// given warranty rate.
 func CalculateFinancials(rate float64, params FinancialParams) (float64, error) {
-	var cumulativeProfit float64
+	schedule, err := CalculateSchedule(rate, params)
+	if err != nil {
+		return 0, err
+	}
+	if len(schedule) == 0 {
+		return 0, nil
+	}
+	return schedule[len(schedule)-1].CumulativeProfit, nil
+}
+
+// CalculateSchedule runs the profit model at the given warranty rate and
+// returns one row per contract year.
+func CalculateSchedule(rate float64, params FinancialParams) ([]YearlyCashFlow, error) {
+	if len(params.Utilization) > 0 && len(params.Utilization) != params.NumYears {
+		return nil, fmt.Errorf("Utilization must have one entry per year (%d), got %d", params.NumYears, len(params.Utilization))
+	}
+
+	schedule := make([]YearlyCashFlow, 0, max(params.NumYears, 0))
+	maintenance := newMaintenanceTracker(params)
+	tsn := params.InitialTSN
+	var cumulativeProfit, cumulativeDiscountedProfit float64
 
 	for year := 1; year <= params.NumYears; year++ {
-		tsn := params.InitialTSN + params.AuHours*float64(year)
+		hours := params.HoursInYear(year)
+		tsn += hours
 		escalatedRate := rate * math.Pow(1+params.RateEscalation/100, float64(year-1))
 
-		engineRevenue := params.AuHours * escalatedRate
+		engineRevenue := hours * escalatedRate
 		aicRevenue := engineRevenue * params.AIC / 100
 		totalRevenue := engineRevenue + aicRevenue
 
-		hsi := tsn >= params.HSITSN && (year == 1 || tsn-params.AuHours < params.HSITSN)
-		overhaul := tsn >= params.OverhaulTSN && (year == 1 || tsn-params.AuHours < params.OverhaulTSN)
-
-		hsiCost := 0.0
-		if hsi {
-			hsiCost = params.HSICost
-		}
-		overhaulCost := 0.0
-		if overhaul {
-			overhaulCost = params.OverhaulCost
+		visits, err := maintenance.advance(tsn-hours, tsn)
+		if err != nil {
+			return nil, err
 		}
+		hsiCost := visits.hsiCost * costEscalation(params.HSICostEscalation, year, params.CostBaseYear)
+		overhaulCost := visits.overhaulCost * costEscalation(params.OverhaulCostEscalation, year, params.CostBaseYear)
 		totalCost := hsiCost + overhaulCost
 		totalProfit := totalRevenue - totalCost
 		cumulativeProfit += totalProfit
+		discountedProfit := totalProfit * discountFactor(params.DiscountRate, year, params.DiscountConvention)
+		cumulativeDiscountedProfit += discountedProfit
+
+		schedule = append(schedule, YearlyCashFlow{
+			Year:                year,
+			Hours:               hours,
+			TSN:                 tsn,
+			EscalatedRate:       escalatedRate,
+			EngineRevenue:       engineRevenue,
+			AICRevenue:          aicRevenue,
+			TotalRevenue:        totalRevenue,
+			HSICount:            visits.hsiCount,
+			OverhaulCount:       visits.overhaulCount,
+			NominalHSICost:      visits.hsiCost,
+			NominalOverhaulCost: visits.overhaulCost,
+			HSICost:             hsiCost,
+			OverhaulCost:        overhaulCost,
+			TotalCost:           totalCost,
+			Profit:              totalProfit,
+			CumulativeProfit:    cumulativeProfit,
+
+			DiscountedProfit:           discountedProfit,
+			CumulativeDiscountedProfit: cumulativeDiscountedProfit,
+		})
 	}
 
-	return cumulativeProfit, nil
+	return schedule, nil
 }
 
+// costEscalation returns the factor that takes a cost quoted in baseYear
+// money to the given contract year.
+func costEscalation(percent float64, year, baseYear int) float64 {
+	if percent == 0 {
+		return 1
+	}
+	if baseYear == 0 {
+		baseYear = 1
+	}
+	return math.Pow(1+percent/100, float64(year-baseYear))
+}
+
+// GoalSeek finds the rate at which CalculateFinancials reaches targetProfit,
+// using the solver selected by params.Solver.
 func GoalSeek(targetProfit float64, params FinancialParams, initialGuess float64) (float64, int, error) {
+	solver, err := NewSolver(params.Solver)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	objective := func(rate float64) (float64, error) {
 		profit, err := CalculateFinancials(rate, params)
 		if err != nil {
@@ -44,17 +131,53 @@ func GoalSeek(targetProfit float64, params FinancialParams, initialGuess float64
 		return profit - targetProfit, nil
 	}
 
-	derivative := func(rate float64) (float64, error) {
-		epsilon := 1e-6
-		f1, err1 := objective(rate + epsilon)
-		f2, err2 := objective(rate)
-		if err1 != nil || err2 != nil {
-			return 0, fmt.Errorf("error calculating derivative")
+	return solver.Solve(objective, initialGuess)
+}
+
+// GoalSeekField finds the value of the named FinancialParams field at which
+// the model reaches the target selected by params.SeekTarget. When solving
+// for SolveForRate the rate starts from params.InitialRate; for any other
+// field the warranty rate is held at params.InitialRate and the field's
+// current value is the initial guess.
+func GoalSeekField(field string, params FinancialParams) (float64, int, error) {
+	solver, err := NewSolver(params.Solver)
+	if err != nil {
+		return 0, 0, err
+	}
+	target, err := seekObjective(params)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if field == "" || field == SolveForRate {
+		objective := func(rate float64) (float64, error) {
+			return target(rate, params)
 		}
-		return (f1 - f2) / epsilon, nil
+		return solver.Solve(objective, params.InitialRate)
+	}
+
+	p := params
+	value, err := p.Field(field)
+	if err != nil {
+		return 0, 0, err
+	}
+	initialGuess := *value
+
+	objective := func(x float64) (float64, error) {
+		*value = x
+		return target(p.InitialRate, p)
+	}
+
+	solved, iterations, err := solver.Solve(objective, initialGuess)
+	if err != nil {
+		return 0, iterations, err
 	}
 
-	return NewtonRaphson(objective, derivative, initialGuess, 1e-8, 100)
+	*value = solved
+	if err := p.Validate(); err != nil {
+		return 0, iterations, fmt.Errorf("%w: solved %s = %g is outside the valid range: %v", ErrNoSolution, field, solved, err)
+	}
+	return solved, iterations, nil
 }
 
 func NewtonRaphson(f, df func(float64) (float64, error), x0, xtol float64, maxIter int) (float64, int, error) {
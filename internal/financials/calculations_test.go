@@ -3,13 +3,15 @@
 package financials
 
 import (
+	"errors"
 	"financialapi/pkg/testutils"
+	"math"
 	"testing"
 )
 
-func TestCalculateFinancials(t *testing.T) {
-	// This test remains unchanged
-	params := FinancialParams{
+// getTestParams returns the contract the tests vary one field of at a time.
+func getTestParams() FinancialParams {
+	return FinancialParams{
 		NumYears:       10,
 		AuHours:        450,
 		InitialTSN:     100,
@@ -22,6 +24,10 @@ func TestCalculateFinancials(t *testing.T) {
 		TargetProfit:   3000000,
 		InitialRate:    320,
 	}
+}
+
+func TestCalculateFinancials(t *testing.T) {
+	params := getTestParams()
 
 	profit, err := CalculateFinancials(params.InitialRate, params)
 	testutils.AssertNoError(t, err)
@@ -32,20 +38,7 @@ func TestCalculateFinancials(t *testing.T) {
 }
 
 func TestGoalSeek(t *testing.T) {
-	// This test remains unchanged
-	params := FinancialParams{
-		NumYears:       10,
-		AuHours:        450,
-		InitialTSN:     100,
-		RateEscalation: 5,
-		AIC:            10,
-		HSITSN:         1000,
-		OverhaulTSN:    3000,
-		HSICost:        50000,
-		OverhaulCost:   100000,
-		TargetProfit:   3000000,
-		InitialRate:    320,
-	}
+	params := getTestParams()
 
 	optimalRate, iterations, err := GoalSeek(params.TargetProfit, params, params.InitialRate)
 	testutils.AssertNoError(t, err)
@@ -57,4 +50,194 @@ func TestGoalSeek(t *testing.T) {
 	if iterations <= 0 {
 		t.Errorf("Expected positive number of iterations, got %d", iterations)
 	}
-}
\ No newline at end of file
+}
+
+func TestGoalSeekField(t *testing.T) {
+	params := getTestParams()
+
+	tests := []struct {
+		field    string
+		expected float64
+	}{
+		{"overhaulCost", 250000},
+		{"auHours", 520},
+		{"rateEscalation", 7.5},
+		{"aic", 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			// Derive the target from a known field value, then solve back for it.
+			expected := params
+			field, err := expected.Field(tt.field)
+			testutils.AssertNoError(t, err)
+			*field = tt.expected
+			target, err := CalculateFinancials(params.InitialRate, expected)
+			testutils.AssertNoError(t, err)
+
+			p := params
+			p.SolveFor = tt.field
+			p.TargetProfit = target
+			testutils.AssertNoError(t, p.Validate())
+
+			solved, _, err := GoalSeekField(tt.field, p)
+			testutils.AssertNoError(t, err)
+			if math.Abs(solved-tt.expected) > 1e-6 {
+				t.Errorf("Expected %s = %f, got %f", tt.field, tt.expected, solved)
+			}
+		})
+	}
+}
+
+func TestGoalSeekFieldInvalid(t *testing.T) {
+	params := getTestParams()
+
+	p := params
+	p.SolveFor = "numYears"
+	testutils.AssertError(t, p.Validate())
+
+	// Reaching the target at this rate would need a negative overhaul cost.
+	_, _, err := GoalSeekField("overhaulCost", params)
+	testutils.AssertError(t, err)
+	testutils.AssertEqual(t, true, errors.Is(err, ErrNoSolution))
+}
+
+func TestCalculateSchedule(t *testing.T) {
+	params := getTestParams()
+
+	schedule, err := CalculateSchedule(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, params.NumYears, len(schedule))
+
+	profit, err := CalculateFinancials(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, profit, schedule[len(schedule)-1].CumulativeProfit)
+
+	var total float64
+	for i, row := range schedule {
+		testutils.AssertEqual(t, i+1, row.Year)
+		total += row.Profit
+		if math.Abs(row.Profit-(row.TotalRevenue-row.TotalCost)) > 1e-9 {
+			t.Errorf("Year %d profit %f does not equal revenue less cost", row.Year, row.Profit)
+		}
+	}
+	if math.Abs(total-profit) > 1e-6 {
+		t.Errorf("Sum of yearly profit %f does not match cumulative profit %f", total, profit)
+	}
+
+	// TSN reaches HSITSN (1000) in year 2 and OverhaulTSN (3000) in year 7.
+	testutils.AssertEqual(t, 1, schedule[1].HSICount)
+	testutils.AssertEqual(t, params.HSICost, schedule[1].HSICost)
+	testutils.AssertEqual(t, 1, schedule[6].OverhaulCount)
+	testutils.AssertEqual(t, params.OverhaulCost, schedule[6].OverhaulCost)
+}
+
+func TestCalculateScheduleRecurringMaintenance(t *testing.T) {
+	params := getTestParams()
+	params.NumYears = 20
+	params.HSIInterval, params.OverhaulInterval = 1000, 3000
+	params.HSICostSchedule = []float64{40000, 60000}
+	testutils.AssertNoError(t, params.Validate())
+
+	schedule, err := CalculateSchedule(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+
+	// HSIs fall due every 1000 hours and overhauls every 3000. Each overhaul
+	// supersedes the HSI due at the same TSN and restarts the HSI cycle.
+	expectedHSI := map[int]float64{2: 40000, 5: 60000, 9: 50000, 11: 50000, 16: 50000, 18: 50000}
+	expectedOverhaul := map[int]bool{7: true, 14: true, 20: true}
+
+	for _, row := range schedule {
+		hsiCost, hsi := expectedHSI[row.Year]
+		if hsi {
+			testutils.AssertEqual(t, 1, row.HSICount)
+			testutils.AssertEqual(t, hsiCost, row.HSICost)
+		} else {
+			testutils.AssertEqual(t, 0, row.HSICount)
+		}
+
+		if expectedOverhaul[row.Year] {
+			testutils.AssertEqual(t, 1, row.OverhaulCount)
+			testutils.AssertEqual(t, params.OverhaulCost, row.OverhaulCost)
+		} else {
+			testutils.AssertEqual(t, 0, row.OverhaulCount)
+		}
+	}
+}
+
+func TestCalculateScheduleCostEscalation(t *testing.T) {
+	params := getTestParams()
+	params.HSICostEscalation, params.OverhaulCostEscalation = 3, 4
+	params.CostBaseYear = 3
+	testutils.AssertNoError(t, params.Validate())
+
+	schedule, err := CalculateSchedule(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+
+	// The HSI in year 2 is one year before the base year, the overhaul in
+	// year 7 four years after it.
+	hsi := schedule[1]
+	testutils.AssertEqual(t, params.HSICost, hsi.NominalHSICost)
+	if math.Abs(hsi.HSICost-params.HSICost/1.03) > 1e-6 {
+		t.Errorf("Expected escalated HSI cost %f, got %f", params.HSICost/1.03, hsi.HSICost)
+	}
+
+	overhaul := schedule[6]
+	testutils.AssertEqual(t, params.OverhaulCost, overhaul.NominalOverhaulCost)
+	expected := params.OverhaulCost * math.Pow(1.04, 4)
+	if math.Abs(overhaul.OverhaulCost-expected) > 1e-6 {
+		t.Errorf("Expected escalated overhaul cost %f, got %f", expected, overhaul.OverhaulCost)
+	}
+	testutils.AssertEqual(t, overhaul.HSICost+overhaul.OverhaulCost, overhaul.TotalCost)
+
+	flat := params
+	flat.HSICostEscalation, flat.OverhaulCostEscalation = 0, 0
+	escalatedProfit, err := CalculateFinancials(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+	flatProfit, err := CalculateFinancials(params.InitialRate, flat)
+	testutils.AssertNoError(t, err)
+	if escalatedProfit >= flatProfit {
+		t.Errorf("Expected cost escalation to reduce profit, got %f (flat %f)", escalatedProfit, flatProfit)
+	}
+}
+
+func TestCalculateScheduleUtilizationProfile(t *testing.T) {
+	params := getTestParams()
+	params.NumYears, params.AuHours = 5, 0
+	params.Utilization = []float64{200, 400, 600, 600, 300}
+	params.TargetProfit = 1000000
+	testutils.AssertNoError(t, params.Validate())
+
+	schedule, err := CalculateSchedule(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+
+	tsn := params.InitialTSN
+	for i, row := range schedule {
+		tsn += params.Utilization[i]
+		testutils.AssertEqual(t, params.Utilization[i], row.Hours)
+		testutils.AssertEqual(t, tsn, row.TSN)
+		testutils.AssertEqual(t, row.Hours*row.EscalatedRate, row.EngineRevenue)
+	}
+
+	// TSN reaches 1000 in year 3 (100+200+400+600 = 1300).
+	testutils.AssertEqual(t, 0, schedule[1].HSICount)
+	testutils.AssertEqual(t, 1, schedule[2].HSICount)
+
+	// A constant profile matches AuHours.
+	flat := params
+	flat.Utilization = []float64{450, 450, 450, 450, 450}
+	constant := params
+	constant.Utilization = nil
+	constant.AuHours = 450
+	flatProfit, err := CalculateFinancials(params.InitialRate, flat)
+	testutils.AssertNoError(t, err)
+	constantProfit, err := CalculateFinancials(params.InitialRate, constant)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, constantProfit, flatProfit)
+
+	short := params
+	short.Utilization = []float64{450, 450}
+	testutils.AssertError(t, short.Validate())
+	_, err = CalculateSchedule(params.InitialRate, short)
+	testutils.AssertError(t, err)
+}
@@ -0,0 +1,122 @@
+package financials
+
+import (
+	"fmt"
+	"math"
+)
+
+// Discounting conventions for FinancialParams.DiscountConvention.
+const (
+	EndOfYear = "end-of-year"
+	MidYear   = "mid-year"
+)
+
+// Goal seek targets for FinancialParams.SeekTarget.
+const (
+	SeekProfit = "profit"
+	SeekNPV    = "npv"
+	SeekIRR    = "irr"
+)
+
+// discountFactor returns the present value of 1 received in the given
+// contract year at ratePercent per year. Under the mid-year convention cash
+// is assumed to arrive halfway through the year.
+func discountFactor(ratePercent float64, year int, convention string) float64 {
+	return math.Pow(1+ratePercent/100, -discountTime(year, convention))
+}
+
+func discountTime(year int, convention string) float64 {
+	if convention == MidYear {
+		return float64(year) - 0.5
+	}
+	return float64(year)
+}
+
+// NPV discounts the yearly profits in schedule at ratePercent per year.
+func NPV(schedule []YearlyCashFlow, ratePercent float64, convention string) float64 {
+	var npv float64
+	for _, row := range schedule {
+		npv += row.Profit * discountFactor(ratePercent, row.Year, convention)
+	}
+	return npv
+}
+
+// IRR returns the discount rate, in percent per year, at which the NPV of
+// the yearly profits in schedule is zero. It is undefined unless the profits
+// change sign.
+func IRR(schedule []YearlyCashFlow, convention string) (float64, error) {
+	var positive, negative bool
+	for _, row := range schedule {
+		positive = positive || row.Profit > 0
+		negative = negative || row.Profit < 0
+	}
+	if !positive || !negative {
+		return 0, fmt.Errorf("IRR is undefined: yearly profits do not change sign")
+	}
+
+	// Solve for the continuously compounded rate so the search can range
+	// over all reals without crossing the -100% pole.
+	npv := func(logRate float64) (float64, error) {
+		var v float64
+		for _, row := range schedule {
+			v += row.Profit * math.Exp(-logRate*discountTime(row.Year, convention))
+		}
+		return v, nil
+	}
+
+	logRate, _, err := BrentSolver{Tolerance: DefaultTolerance, MaxIterations: DefaultMaxIterations}.Solve(npv, math.Log1p(0.1))
+	if err != nil {
+		return 0, fmt.Errorf("IRR not found: %v", err)
+	}
+	return math.Expm1(logRate) * 100, nil
+}
+
+// DiscountedPaybackYear returns the first contract year from which the
+// cumulative discounted profit stays non-negative, or 0 if it never does.
+func DiscountedPaybackYear(schedule []YearlyCashFlow) int {
+	payback := 0
+	for _, row := range schedule {
+		if row.CumulativeDiscountedProfit < 0 {
+			payback = 0
+		} else if payback == 0 {
+			payback = row.Year
+		}
+	}
+	return payback
+}
+
+// seekObjective returns the goal seek objective selected by
+// params.SeekTarget: the distance of the model from its target at the given
+// rate and params.
+func seekObjective(params FinancialParams) (func(rate float64, p FinancialParams) (float64, error), error) {
+	switch params.SeekTarget {
+	case "", SeekProfit:
+		return func(rate float64, p FinancialParams) (float64, error) {
+			profit, err := CalculateFinancials(rate, p)
+			if err != nil {
+				return 0, err
+			}
+			return profit - p.TargetProfit, nil
+		}, nil
+	case SeekNPV:
+		return func(rate float64, p FinancialParams) (float64, error) {
+			schedule, err := CalculateSchedule(rate, p)
+			if err != nil {
+				return 0, err
+			}
+			return NPV(schedule, p.DiscountRate, p.DiscountConvention) - p.TargetNPV, nil
+		}, nil
+	case SeekIRR:
+		// The IRR equals the target exactly when the NPV discounted at the
+		// target is zero, which avoids a nested IRR solve per evaluation.
+		return func(rate float64, p FinancialParams) (float64, error) {
+			schedule, err := CalculateSchedule(rate, p)
+			if err != nil {
+				return 0, err
+			}
+			return NPV(schedule, p.TargetIRR, p.DiscountConvention), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown seekTarget %q", params.SeekTarget)
+	}
+}
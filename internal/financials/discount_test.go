@@ -0,0 +1,108 @@
+// File: internal/financials/discount_test.go
+
+package financials
+
+import (
+	"financialapi/pkg/testutils"
+	"math"
+	"testing"
+)
+
+func TestNPVAndIRR(t *testing.T) {
+	schedule := []YearlyCashFlow{
+		{Year: 1, Profit: -1000},
+		{Year: 2, Profit: 600},
+		{Year: 3, Profit: 600},
+	}
+
+	npv := NPV(schedule, 10, EndOfYear)
+	expected := -1000/1.1 + 600/math.Pow(1.1, 2) + 600/math.Pow(1.1, 3)
+	if math.Abs(npv-expected) > 1e-9 {
+		t.Errorf("Expected NPV %f, got %f", expected, npv)
+	}
+
+	midYear := NPV(schedule, 10, MidYear)
+	if math.Abs(midYear-expected*math.Sqrt(1.1)) > 1e-9 {
+		t.Errorf("Expected mid-year NPV %f, got %f", expected*math.Sqrt(1.1), midYear)
+	}
+
+	irr, err := IRR(schedule, EndOfYear)
+	testutils.AssertNoError(t, err)
+	if math.Abs(NPV(schedule, irr, EndOfYear)) > 1e-6 {
+		t.Errorf("Expected zero NPV at the IRR (%f%%), got %f", irr, NPV(schedule, irr, EndOfYear))
+	}
+	if math.Abs(irr-13.0662) > 1e-3 {
+		t.Errorf("Expected IRR of about 13.0662%%, got %f", irr)
+	}
+
+	_, err = IRR([]YearlyCashFlow{{Year: 1, Profit: 10}, {Year: 2, Profit: 10}}, EndOfYear)
+	testutils.AssertError(t, err)
+}
+
+func TestDiscountedPaybackYear(t *testing.T) {
+	schedule := []YearlyCashFlow{
+		{Year: 1, CumulativeDiscountedProfit: -500},
+		{Year: 2, CumulativeDiscountedProfit: 100},
+		{Year: 3, CumulativeDiscountedProfit: -50},
+		{Year: 4, CumulativeDiscountedProfit: 200},
+		{Year: 5, CumulativeDiscountedProfit: 400},
+	}
+	testutils.AssertEqual(t, 4, DiscountedPaybackYear(schedule))
+	testutils.AssertEqual(t, 0, DiscountedPaybackYear(schedule[:3]))
+}
+
+func TestGoalSeekNPVAndIRR(t *testing.T) {
+	// An overdue overhaul in year 1 makes the first year a loss, so the
+	// profits have an IRR.
+	params := getTestParams()
+	params.InitialTSN, params.HSITSN = 3100, 4000
+	params.OverhaulCost = 1000000
+	params.DiscountRate, params.DiscountConvention = 8, MidYear
+
+	npvParams := params
+	npvParams.SeekTarget = SeekNPV
+	npvParams.TargetNPV = 1500000
+	testutils.AssertNoError(t, npvParams.Validate())
+
+	rate, _, err := GoalSeekField(SolveForRate, npvParams)
+	testutils.AssertNoError(t, err)
+	schedule, err := CalculateSchedule(rate, npvParams)
+	testutils.AssertNoError(t, err)
+	if npv := NPV(schedule, params.DiscountRate, params.DiscountConvention); math.Abs(npv-npvParams.TargetNPV) > 1e-6 {
+		t.Errorf("Expected NPV %f at rate %f, got %f", npvParams.TargetNPV, rate, npv)
+	}
+	if last := schedule[len(schedule)-1].CumulativeDiscountedProfit; math.Abs(last-npvParams.TargetNPV) > 1e-6 {
+		t.Errorf("Expected cumulative discounted profit %f, got %f", npvParams.TargetNPV, last)
+	}
+
+	// The discount rate at which the profits at the initial rate are worth
+	// what they are at 12%.
+	schedule, err = CalculateSchedule(params.InitialRate, params)
+	testutils.AssertNoError(t, err)
+	discountParams := params
+	discountParams.SeekTarget = SeekNPV
+	discountParams.TargetNPV = NPV(schedule, 12, params.DiscountConvention)
+	discountParams.SolveFor = "discountRate"
+	testutils.AssertNoError(t, discountParams.Validate())
+
+	discountRate, _, err := GoalSeekField("discountRate", discountParams)
+	testutils.AssertNoError(t, err)
+	if math.Abs(discountRate-12) > 1e-6 {
+		t.Errorf("Expected a discount rate of 12%%, got %f%%", discountRate)
+	}
+
+	irrParams := params
+	irrParams.SeekTarget = SeekIRR
+	irrParams.TargetIRR = 25
+	testutils.AssertNoError(t, irrParams.Validate())
+
+	rate, _, err = GoalSeekField(SolveForRate, irrParams)
+	testutils.AssertNoError(t, err)
+	schedule, err = CalculateSchedule(rate, irrParams)
+	testutils.AssertNoError(t, err)
+	irr, err := IRR(schedule, irrParams.DiscountConvention)
+	testutils.AssertNoError(t, err)
+	if math.Abs(irr-irrParams.TargetIRR) > 1e-6 {
+		t.Errorf("Expected IRR %f%% at rate %f, got %f%%", irrParams.TargetIRR, rate, irr)
+	}
+}
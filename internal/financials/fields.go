@@ -0,0 +1,48 @@
+package financials
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SolveForRate is the default goal seek target: the warranty rate passed to
+// CalculateFinancials.
+const SolveForRate = "rate"
+
+// solvableFields maps the JSON name of every continuous FinancialParams input
+// to the field itself. NumYears is discrete, and TargetProfit and InitialRate
+// are goal seek inputs rather than model inputs, so they are not listed.
+var solvableFields = map[string]func(p *FinancialParams) *float64{
+	"auHours":                func(p *FinancialParams) *float64 { return &p.AuHours },
+	"initialTSN":             func(p *FinancialParams) *float64 { return &p.InitialTSN },
+	"rateEscalation":         func(p *FinancialParams) *float64 { return &p.RateEscalation },
+	"aic":                    func(p *FinancialParams) *float64 { return &p.AIC },
+	"hsitsn":                 func(p *FinancialParams) *float64 { return &p.HSITSN },
+	"overhaulTSN":            func(p *FinancialParams) *float64 { return &p.OverhaulTSN },
+	"hsiCost":                func(p *FinancialParams) *float64 { return &p.HSICost },
+	"overhaulCost":           func(p *FinancialParams) *float64 { return &p.OverhaulCost },
+	"hsiInterval":            func(p *FinancialParams) *float64 { return &p.HSIInterval },
+	"overhaulInterval":       func(p *FinancialParams) *float64 { return &p.OverhaulInterval },
+	"hsiCostEscalation":      func(p *FinancialParams) *float64 { return &p.HSICostEscalation },
+	"overhaulCostEscalation": func(p *FinancialParams) *float64 { return &p.OverhaulCostEscalation },
+	"discountRate":           func(p *FinancialParams) *float64 { return &p.DiscountRate },
+}
+
+// Field returns a pointer to the numeric field with the given JSON name.
+func (p *FinancialParams) Field(name string) (*float64, error) {
+	field, ok := solvableFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q, expected one of %v", name, FieldNames())
+	}
+	return field(p), nil
+}
+
+// FieldNames lists the JSON names accepted by Field, sorted.
+func FieldNames() []string {
+	names := make([]string, 0, len(solvableFields))
+	for name := range solvableFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,95 @@
+package financials
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxShopVisitsPerYear guards against intervals so short that a single
+// contract year would schedule an unbounded number of shop visits.
+const maxShopVisitsPerYear = 1000
+
+// shopVisits summarises the maintenance events falling in one contract year.
+type shopVisits struct {
+	hsiCount      int
+	overhaulCount int
+	hsiCost       float64
+	overhaulCost  float64
+}
+
+// maintenanceTracker follows the HSI and overhaul cycles of the engine
+// through the contract.
+//
+// The first HSI and overhaul fall due at HSITSN and OverhaulTSN. With a zero
+// interval each happens once; otherwise the next one falls due an interval
+// after the previous one. An overhaul includes the hot section work, so it
+// restarts the HSI cycle and supersedes an HSI due at the same time. Events
+// already overdue at the start of the contract happen at once.
+type maintenanceTracker struct {
+	params        FinancialParams
+	nextHSI       float64
+	nextOverhaul  float64
+	hsiCount      int
+	overhaulCount int
+}
+
+func newMaintenanceTracker(params FinancialParams) *maintenanceTracker {
+	return &maintenanceTracker{
+		params:       params,
+		nextHSI:      params.HSITSN,
+		nextOverhaul: params.OverhaulTSN,
+	}
+}
+
+// advance flies the engine from tsnStart to tsnEnd and returns the shop
+// visits that fall due on the way.
+func (m *maintenanceTracker) advance(tsnStart, tsnEnd float64) (shopVisits, error) {
+	var visits shopVisits
+
+	for n := 0; ; n++ {
+		if n >= maxShopVisitsPerYear {
+			return shopVisits{}, fmt.Errorf("more than %d shop visits in one year, check hsiInterval and overhaulInterval", maxShopVisitsPerYear)
+		}
+
+		due := math.Min(m.nextHSI, m.nextOverhaul)
+		if due > tsnEnd {
+			return visits, nil
+		}
+		at := math.Max(due, tsnStart)
+
+		if m.nextOverhaul <= m.nextHSI {
+			m.overhaulCount++
+			visits.overhaulCount++
+			visits.overhaulCost += shopVisitCost(m.params.OverhaulCostSchedule, m.overhaulCount, m.params.OverhaulCost)
+
+			m.nextOverhaul = nextDue(at, m.params.OverhaulInterval)
+			if m.params.HSIInterval > 0 {
+				m.nextHSI = at + m.params.HSIInterval
+			}
+			continue
+		}
+
+		m.hsiCount++
+		visits.hsiCount++
+		visits.hsiCost += shopVisitCost(m.params.HSICostSchedule, m.hsiCount, m.params.HSICost)
+		m.nextHSI = nextDue(at, m.params.HSIInterval)
+	}
+}
+
+// nextDue returns the TSN of the next occurrence of an event repeating every
+// interval hours, or +Inf for one-off events.
+func nextDue(at, interval float64) float64 {
+	if interval <= 0 {
+		return math.Inf(1)
+	}
+	return at + interval
+}
+
+// shopVisitCost returns the cost of the n-th (1-based) visit: the n-th entry
+// of costs when there is one, otherwise the flat cost.
+func shopVisitCost(costs []float64, n int, flat float64) float64 {
+	if n <= len(costs) {
+		return costs[n-1]
+	}
+	return flat
+}
@@ -12,17 +12,100 @@ type FinancialParams struct {
 	OverhaulTSN    float64 `json:"overhaulTSN"`
 	HSICost        float64 `json:"hsiCost"`
 	OverhaulCost   float64 `json:"overhaulCost"`
-	TargetProfit   float64 `json:"targetProfit"`
-	InitialRate    float64 `json:"initialRate"`
+
+	// Utilization optionally gives the flight hours for each contract year,
+	// overriding AuHours. Its length must match NumYears.
+	Utilization []float64 `json:"utilization"`
+
+	// HSIInterval and OverhaulInterval (TBO) are the hours between repeat
+	// shop visits; zero means a single visit at HSITSN or OverhaulTSN. The
+	// cost schedules price the n-th visit, falling back to HSICost and
+	// OverhaulCost beyond their length.
+	HSIInterval          float64   `json:"hsiInterval"`
+	OverhaulInterval     float64   `json:"overhaulInterval"`
+	HSICostSchedule      []float64 `json:"hsiCostSchedule"`
+	OverhaulCostSchedule []float64 `json:"overhaulCostSchedule"`
+
+	// HSICostEscalation and OverhaulCostEscalation are percentages per year
+	// applied to shop visit costs quoted in CostBaseYear money. CostBaseYear
+	// is a contract year; zero means year 1.
+	HSICostEscalation      float64 `json:"hsiCostEscalation"`
+	OverhaulCostEscalation float64 `json:"overhaulCostEscalation"`
+	CostBaseYear           int     `json:"costBaseYear"`
+
+	// DiscountRate is the annual discount rate in percent used for NPV and
+	// discounted payback. DiscountConvention is EndOfYear (default) or
+	// MidYear.
+	DiscountRate       float64 `json:"discountRate"`
+	DiscountConvention string  `json:"discountConvention"`
+
+	// SeekTarget selects what goal seek solves for: SeekProfit (default)
+	// reaches TargetProfit, SeekNPV reaches TargetNPV and SeekIRR reaches
+	// TargetIRR, in percent.
+	SeekTarget   string  `json:"seekTarget"`
+	TargetProfit float64 `json:"targetProfit"`
+	TargetNPV    float64 `json:"targetNPV"`
+	TargetIRR    float64 `json:"targetIRR"`
+	InitialRate  float64 `json:"initialRate"`
+
+	// SolveFor names the field goal seek solves for; see GoalSeekField.
+	SolveFor string       `json:"solveFor"`
+	Solver   SolverConfig `json:"solver"`
 }
 
 func (p FinancialParams) Validate() error {
+	if err := p.ValidateModel(); err != nil {
+		return err
+	}
+	if len(p.Utilization) > 0 && p.SolveFor == "auHours" {
+		return fmt.Errorf("cannot solve for auHours when a Utilization profile is given")
+	}
+	switch p.SeekTarget {
+	case "", SeekProfit:
+		if p.TargetProfit <= 0 {
+			return fmt.Errorf("TargetProfit must be positive")
+		}
+	case SeekNPV:
+	case SeekIRR:
+		if p.TargetIRR <= -100 {
+			return fmt.Errorf("TargetIRR must be greater than -100")
+		}
+	default:
+		return fmt.Errorf("SeekTarget must be %q, %q or %q", SeekProfit, SeekNPV, SeekIRR)
+	}
+	if p.InitialRate <= 0 {
+		return fmt.Errorf("InitialRate must be positive")
+	}
+	if p.SolveFor != "" && p.SolveFor != SolveForRate {
+		if _, err := p.Field(p.SolveFor); err != nil {
+			return fmt.Errorf("invalid solveFor: %v", err)
+		}
+	}
+	if err := p.Solver.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateModel checks the inputs of the profit model itself, leaving out
+// the goal seek target and solver settings.
+func (p FinancialParams) ValidateModel() error {
 	if p.NumYears <= 0 {
 		return fmt.Errorf("NumYears must be positive")
 	}
-	if p.AuHours <= 0 {
+	if len(p.Utilization) == 0 && p.AuHours <= 0 {
 		return fmt.Errorf("AuHours must be positive")
 	}
+	if len(p.Utilization) > 0 {
+		if len(p.Utilization) != p.NumYears {
+			return fmt.Errorf("Utilization must have one entry per year (%d), got %d", p.NumYears, len(p.Utilization))
+		}
+		for i, hours := range p.Utilization {
+			if hours < 0 {
+				return fmt.Errorf("Utilization for year %d cannot be negative", i+1)
+			}
+		}
+	}
 	if p.InitialTSN < 0 {
 		return fmt.Errorf("InitialTSN cannot be negative")
 	}
@@ -44,11 +127,47 @@ func (p FinancialParams) Validate() error {
 	if p.OverhaulCost < 0 {
 		return fmt.Errorf("OverhaulCost cannot be negative")
 	}
-	if p.TargetProfit <= 0 {
-		return fmt.Errorf("TargetProfit must be positive")
+	if p.HSICostEscalation < 0 {
+		return fmt.Errorf("HSICostEscalation cannot be negative")
 	}
-	if p.InitialRate <= 0 {
-		return fmt.Errorf("InitialRate must be positive")
+	if p.OverhaulCostEscalation < 0 {
+		return fmt.Errorf("OverhaulCostEscalation cannot be negative")
+	}
+	if p.CostBaseYear < 0 {
+		return fmt.Errorf("CostBaseYear cannot be negative")
+	}
+	if p.HSIInterval < 0 {
+		return fmt.Errorf("HSIInterval cannot be negative")
+	}
+	if p.OverhaulInterval < 0 {
+		return fmt.Errorf("OverhaulInterval cannot be negative")
+	}
+	for i, cost := range p.HSICostSchedule {
+		if cost < 0 {
+			return fmt.Errorf("HSICostSchedule entry %d cannot be negative", i+1)
+		}
+	}
+	for i, cost := range p.OverhaulCostSchedule {
+		if cost < 0 {
+			return fmt.Errorf("OverhaulCostSchedule entry %d cannot be negative", i+1)
+		}
+	}
+	if p.DiscountRate <= -100 {
+		return fmt.Errorf("DiscountRate must be greater than -100")
+	}
+	switch p.DiscountConvention {
+	case "", EndOfYear, MidYear:
+	default:
+		return fmt.Errorf("DiscountConvention must be %q or %q", EndOfYear, MidYear)
 	}
 	return nil
 }
+
+// HoursInYear returns the flight hours for the given contract year: the
+// Utilization entry when a profile is given, AuHours otherwise.
+func (p FinancialParams) HoursInYear(year int) float64 {
+	if len(p.Utilization) > 0 {
+		return p.Utilization[year-1]
+	}
+	return p.AuHours
+}
@@ -0,0 +1,326 @@
+package financials
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Root-finding methods selectable through SolverConfig.Method.
+const (
+	MethodHybrid    = "hybrid"
+	MethodNewton    = "newton"
+	MethodSecant    = "secant"
+	MethodBisection = "bisection"
+	MethodIllinois  = "illinois"
+	MethodBrent     = "brent"
+)
+
+const (
+	DefaultTolerance     = 1e-8
+	DefaultMaxIterations = 100
+
+	// maxBracketExpansions bounds ExpandBracket for the bracketing solvers.
+	// 1.6^50 widens the initial interval by roughly ten orders of magnitude.
+	maxBracketExpansions = 50
+	// derivativeStep is the forward-difference step used by Newton and as
+	// the initial secant step.
+	derivativeStep = 1e-6
+)
+
+// Solver finds a root of f starting from the initial guess x0. It returns
+// the root and the number of iterations used.
+type Solver interface {
+	Solve(f func(float64) (float64, error), x0 float64) (float64, int, error)
+}
+
+// SolverConfig selects the root-finding method for a goal seek request.
+// Zero values fall back to the hybrid method, DefaultTolerance and
+// DefaultMaxIterations. Tolerance is an absolute tolerance on the objective,
+// i.e. on the profit difference.
+type SolverConfig struct {
+	Method        string  `json:"method"`
+	Tolerance     float64 `json:"tolerance"`
+	MaxIterations int     `json:"maxIterations"`
+}
+
+func (c SolverConfig) Validate() error {
+	if _, err := c.method(); err != nil {
+		return err
+	}
+	if c.Tolerance < 0 {
+		return fmt.Errorf("solver tolerance cannot be negative")
+	}
+	if c.MaxIterations < 0 {
+		return fmt.Errorf("solver maxIterations cannot be negative")
+	}
+	return nil
+}
+
+// MethodName returns the normalised name of the configured method, or the
+// raw name when it is unknown.
+func (c SolverConfig) MethodName() string {
+	if m, err := c.method(); err == nil {
+		return m
+	}
+	return c.Method
+}
+
+// method normalises the configured method name, accepting "regula-falsi" as
+// an alias for the Illinois variant.
+func (c SolverConfig) method() (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(c.Method)); m {
+	case "":
+		return MethodHybrid, nil
+	case "regula-falsi", "regulafalsi":
+		return MethodIllinois, nil
+	case MethodHybrid, MethodNewton, MethodSecant, MethodBisection, MethodIllinois, MethodBrent:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown solver method %q", c.Method)
+	}
+}
+
+// NewSolver builds the Solver described by cfg.
+func NewSolver(cfg SolverConfig) (Solver, error) {
+	method, err := cfg.method()
+	if err != nil {
+		return nil, err
+	}
+	tol := cfg.Tolerance
+	if tol == 0 {
+		tol = DefaultTolerance
+	}
+	maxIter := cfg.MaxIterations
+	if maxIter == 0 {
+		maxIter = DefaultMaxIterations
+	}
+
+	switch method {
+	case MethodNewton:
+		return NewtonSolver{Tolerance: tol, MaxIterations: maxIter}, nil
+	case MethodSecant:
+		return SecantSolver{Tolerance: tol, MaxIterations: maxIter}, nil
+	case MethodBisection:
+		return BisectionSolver{Tolerance: tol, MaxIterations: maxIter}, nil
+	case MethodIllinois:
+		return IllinoisSolver{Tolerance: tol, MaxIterations: maxIter}, nil
+	case MethodBrent:
+		return BrentSolver{Tolerance: tol, MaxIterations: maxIter}, nil
+	default:
+		return HybridSolver{Tolerance: tol, MaxIterations: maxIter}, nil
+	}
+}
+
+// NewtonSolver runs NewtonRaphson with a forward-difference derivative.
+type NewtonSolver struct {
+	Tolerance     float64
+	MaxIterations int
+}
+
+func (s NewtonSolver) Solve(f func(float64) (float64, error), x0 float64) (float64, int, error) {
+	derivative := func(x float64) (float64, error) {
+		f1, err1 := f(x + derivativeStep)
+		f2, err2 := f(x)
+		if err1 != nil || err2 != nil {
+			return 0, fmt.Errorf("error calculating derivative")
+		}
+		return (f1 - f2) / derivativeStep, nil
+	}
+	return NewtonRaphson(f, derivative, x0, s.Tolerance, s.MaxIterations)
+}
+
+// SecantSolver replaces Newton's derivative with the slope through the last
+// two iterates, needing one objective evaluation per iteration.
+type SecantSolver struct {
+	Tolerance     float64
+	MaxIterations int
+}
+
+func (s SecantSolver) Solve(f func(float64) (float64, error), x0 float64) (float64, int, error) {
+	x1 := x0 + math.Max(math.Abs(x0)*1e-3, derivativeStep)
+	f0, err := f(x0)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i := 0; i < s.MaxIterations; i++ {
+		f1, err := f(x1)
+		if err != nil {
+			return 0, i, err
+		}
+		if math.Abs(f1) < s.Tolerance {
+			return x1, i + 1, nil
+		}
+		if f1 == f0 {
+			return 0, i, fmt.Errorf("secant slope is zero, can't proceed with secant method")
+		}
+		x0, x1, f0 = x1, x1-f1*(x1-x0)/(f1-f0), f1
+	}
+	return 0, s.MaxIterations, fmt.Errorf("secant method did not converge within %d iterations", s.MaxIterations)
+}
+
+// BisectionSolver halves a bracket found around x0 until the objective is
+// within tolerance. It is slow but cannot diverge.
+type BisectionSolver struct {
+	Tolerance     float64
+	MaxIterations int
+}
+
+func (s BisectionSolver) Solve(f func(float64) (float64, error), x0 float64) (float64, int, error) {
+	a, b, err := ExpandBracket(f, x0, maxBracketExpansions)
+	if err != nil {
+		return 0, 0, err
+	}
+	fa, err := f(a)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := 0; i < s.MaxIterations; i++ {
+		m := a + (b-a)/2
+		fm, err := f(m)
+		if err != nil {
+			return 0, i, err
+		}
+		if math.Abs(fm) < s.Tolerance || m == a || m == b {
+			return m, i + 1, nil
+		}
+		if math.Signbit(fm) == math.Signbit(fa) {
+			a, fa = m, fm
+		} else {
+			b = m
+		}
+	}
+	return 0, s.MaxIterations, fmt.Errorf("bisection did not converge within %d iterations", s.MaxIterations)
+}
+
+// IllinoisSolver is regula falsi with the Illinois modification: when the
+// same end of the bracket is retained twice in a row, its function value is
+// halved so the interval keeps shrinking from both sides.
+type IllinoisSolver struct {
+	Tolerance     float64
+	MaxIterations int
+}
+
+func (s IllinoisSolver) Solve(f func(float64) (float64, error), x0 float64) (float64, int, error) {
+	a, b, err := ExpandBracket(f, x0, maxBracketExpansions)
+	if err != nil {
+		return 0, 0, err
+	}
+	fa, err := f(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	fb, err := f(b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	side := 0
+	for i := 0; i < s.MaxIterations; i++ {
+		c := (a*fb - b*fa) / (fb - fa)
+		fc, err := f(c)
+		if err != nil {
+			return 0, i, err
+		}
+		if math.Abs(fc) < s.Tolerance || c <= math.Min(a, b) || c >= math.Max(a, b) {
+			return c, i + 1, nil
+		}
+
+		if math.Signbit(fc) == math.Signbit(fb) {
+			b, fb = c, fc
+			if side == -1 {
+				fa /= 2
+			}
+			side = -1
+		} else {
+			a, fa = c, fc
+			if side == 1 {
+				fb /= 2
+			}
+			side = 1
+		}
+	}
+	return 0, s.MaxIterations, fmt.Errorf("Illinois method did not converge within %d iterations", s.MaxIterations)
+}
+
+// BrentSolver runs Brent's method on a bracket found around x0.
+type BrentSolver struct {
+	Tolerance     float64
+	MaxIterations int
+}
+
+func (s BrentSolver) Solve(f func(float64) (float64, error), x0 float64) (float64, int, error) {
+	a, b, err := ExpandBracket(f, x0, maxBracketExpansions)
+	if err != nil {
+		return 0, 0, err
+	}
+	return Brent(f, a, b, s.Tolerance, s.MaxIterations)
+}
+
+// HybridSolver first checks whether f is affine, as the profit model is in
+// the warranty rate, and if so solves it directly. Otherwise it tries Newton,
+// which converges in a couple of iterations on smooth objectives, and falls
+// back to Brent when Newton stalls on kinked objectives (step costs) or poor
+// initial guesses.
+type HybridSolver struct {
+	Tolerance     float64
+	MaxIterations int
+}
+
+func (s HybridSolver) Solve(f func(float64) (float64, error), x0 float64) (float64, int, error) {
+	x, ok, err := SolveAffine(f, x0, s.Tolerance)
+	if err != nil {
+		return 0, 0, err
+	}
+	if ok {
+		return x, 1, nil
+	}
+
+	x, iterations, err := NewtonSolver(s).Solve(f, x0)
+	if err == nil {
+		return x, iterations, nil
+	}
+
+	x, brentIterations, err := BrentSolver(s).Solve(f, x0)
+	return x, iterations + brentIterations, err
+}
+
+// SolveAffine probes f at x0 and a second point, solves the line through
+// them and verifies the result with a third evaluation. It reports false
+// when f is not affine to within tol around x0, in which case the caller
+// should iterate instead. A point that misses tol only by rounding gets one
+// correction step along the probed slope.
+func SolveAffine(f func(float64) (float64, error), x0, tol float64) (float64, bool, error) {
+	x1 := x0 + math.Max(math.Abs(x0)*0.1, 1)
+	f0, err := f(x0)
+	if err != nil {
+		return 0, false, err
+	}
+	f1, err := f(x1)
+	if err != nil {
+		return 0, false, err
+	}
+
+	slope := (f1 - f0) / (x1 - x0)
+	if slope == 0 || math.IsNaN(slope) || math.IsInf(slope, 0) {
+		return 0, false, nil
+	}
+
+	x := x0 - f0/slope
+	for attempt := 0; attempt < 2; attempt++ {
+		fx, err := f(x)
+		if err != nil {
+			return 0, false, err
+		}
+		if math.Abs(fx) < tol {
+			return x, true, nil
+		}
+		// Anything beyond rounding noise in the probes means f is not affine.
+		if math.Abs(fx) > 1e-9*(math.Abs(f0)+math.Abs(f1)) {
+			return 0, false, nil
+		}
+		x -= fx / slope
+	}
+	return 0, false, nil
+}
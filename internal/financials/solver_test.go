@@ -0,0 +1,99 @@
+// File: internal/financials/solver_test.go
+
+package financials
+
+import (
+	"financialapi/pkg/testutils"
+	"math"
+	"testing"
+)
+
+func TestSolverMethods(t *testing.T) {
+	params := getTestParams()
+
+	methods := []string{"", MethodHybrid, MethodNewton, MethodSecant, MethodBisection, MethodIllinois, "regula-falsi", MethodBrent}
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			p := params
+			p.Solver = SolverConfig{Method: method, Tolerance: 1e-6}
+			testutils.AssertNoError(t, p.Validate())
+
+			rate, iterations, err := GoalSeek(p.TargetProfit, p, p.InitialRate)
+			testutils.AssertNoError(t, err)
+
+			profit, err := CalculateFinancials(rate, p)
+			testutils.AssertNoError(t, err)
+			if math.Abs(profit-p.TargetProfit) > 1e-6 {
+				t.Errorf("Expected profit %f, got %f (rate %f)", p.TargetProfit, profit, rate)
+			}
+			if iterations <= 0 {
+				t.Errorf("Expected positive number of iterations, got %d", iterations)
+			}
+		})
+	}
+}
+
+func TestSolverNonlinear(t *testing.T) {
+	f := func(x float64) (float64, error) {
+		return math.Exp(x) - 10, nil
+	}
+
+	for _, method := range []string{MethodNewton, MethodSecant, MethodBisection, MethodIllinois, MethodBrent} {
+		t.Run(method, func(t *testing.T) {
+			solver, err := NewSolver(SolverConfig{Method: method, Tolerance: 1e-10, MaxIterations: 200})
+			testutils.AssertNoError(t, err)
+
+			root, _, err := solver.Solve(f, 1)
+			testutils.AssertNoError(t, err)
+			if math.Abs(root-math.Log(10)) > 1e-9 {
+				t.Errorf("Expected root %f, got %f", math.Log(10), root)
+			}
+		})
+	}
+}
+
+func TestSolverConfigValidate(t *testing.T) {
+	testutils.AssertError(t, SolverConfig{Method: "golden-section"}.Validate())
+	testutils.AssertError(t, SolverConfig{Tolerance: -1}.Validate())
+	testutils.AssertError(t, SolverConfig{MaxIterations: -1}.Validate())
+	testutils.AssertEqual(t, MethodIllinois, SolverConfig{Method: "Regula-Falsi"}.MethodName())
+}
+
+func TestSolveAffine(t *testing.T) {
+	params := getTestParams()
+
+	// Profit is affine in the rate.
+	evaluations := 0
+	byRate := func(rate float64) (float64, error) {
+		evaluations++
+		profit, err := CalculateFinancials(rate, params)
+		return profit - params.TargetProfit, err
+	}
+	rate, ok, err := SolveAffine(byRate, params.InitialRate, DefaultTolerance)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, true, ok)
+	if evaluations > 4 {
+		t.Errorf("Expected at most 4 model evaluations, got %d", evaluations)
+	}
+	if math.Abs(rate-505.93820432563325) > 1e-6 {
+		t.Errorf("Expected rate 505.938204, got %f", rate)
+	}
+
+	// Profit compounds with the rate escalation, so it is not affine in it.
+	byEscalation := func(escalation float64) (float64, error) {
+		p := params
+		p.RateEscalation = escalation
+		profit, err := CalculateFinancials(params.InitialRate, p)
+		return profit - 2000000, err
+	}
+	_, ok, err = SolveAffine(byEscalation, params.RateEscalation, DefaultTolerance)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, false, ok)
+
+	// The hybrid solver falls back to iterating and still finds the root.
+	escalation, _, err := HybridSolver{Tolerance: DefaultTolerance, MaxIterations: DefaultMaxIterations}.Solve(byEscalation, params.RateEscalation)
+	testutils.AssertNoError(t, err)
+	if residual, _ := byEscalation(escalation); math.Abs(residual) > DefaultTolerance {
+		t.Errorf("Expected residual below tolerance, got %g", residual)
+	}
+}
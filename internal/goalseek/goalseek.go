@@ -28,20 +28,73 @@ func (gs *GoalSeek) Validate() error {
 }
 
 func (gs *GoalSeek) Compute() error {
-	optimalRate, iterations, err := financials.GoalSeek(gs.Params.TargetProfit, gs.Params, gs.Params.InitialRate)
+	solveFor := gs.Params.SolveFor
+	if solveFor == "" {
+		solveFor = financials.SolveForRate
+	}
+
+	solved, iterations, err := financials.GoalSeekField(solveFor, gs.Params)
 	if err != nil {
 		return err
 	}
 
-	finalCumulativeProfit, err := financials.CalculateFinancials(optimalRate, gs.Params)
+	params := gs.Params
+	rate := params.InitialRate
+	if solveFor == financials.SolveForRate {
+		rate = solved
+	} else {
+		field, err := params.Field(solveFor)
+		if err != nil {
+			return err
+		}
+		*field = solved
+	}
+
+	schedule, err := financials.CalculateSchedule(rate, params)
 	if err != nil {
 		return err
 	}
+	var finalCumulativeProfit, nominalMaintenanceCost, maintenanceCost float64
+	for _, row := range schedule {
+		finalCumulativeProfit = row.CumulativeProfit
+		nominalMaintenanceCost += row.NominalHSICost + row.NominalOverhaulCost
+		maintenanceCost += row.TotalCost
+	}
+
+	seekTarget := gs.Params.SeekTarget
+	if seekTarget == "" {
+		seekTarget = financials.SeekProfit
+	}
+
+	var irr interface{}
+	if v, err := financials.IRR(schedule, params.DiscountConvention); err == nil {
+		irr = v
+	}
+	var discountedPaybackYear interface{}
+	if year := financials.DiscountedPaybackYear(schedule); year > 0 {
+		discountedPaybackYear = year
+	}
 
 	gs.result = map[string]interface{}{
-		"optimalWarrantyRate":   optimalRate,
+		"solveFor":              solveFor,
+		"solvedValue":           solved,
 		"iterations":            iterations,
 		"finalCumulativeProfit": finalCumulativeProfit,
+		"solverMethod":          gs.Params.Solver.MethodName(),
+		"schedule":              schedule,
+
+		"totalNominalMaintenanceCost": nominalMaintenanceCost,
+		"totalMaintenanceCost":        maintenanceCost,
+
+		"seekTarget":            seekTarget,
+		"npv":                   financials.NPV(schedule, params.DiscountRate, params.DiscountConvention),
+		"irr":                   irr,
+		"discountedPaybackYear": discountedPaybackYear,
+	}
+	if solveFor == financials.SolveForRate {
+		gs.result["optimalWarrantyRate"] = rate
+	} else {
+		gs.result["warrantyRate"] = rate
 	}
 
 	return nil
@@ -97,3 +97,36 @@ func BenchmarkCalculateParallel(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkCalculateBySolver compares the default solver, which takes the
+// closed-form path for the affine rate objective, with the iterative methods.
+func BenchmarkCalculateBySolver(b *testing.B) {
+	params := financials.FinancialParams{
+		NumYears:       10,
+		AuHours:        450,
+		InitialTSN:     100,
+		RateEscalation: 5,
+		AIC:            10,
+		HSITSN:         1000,
+		OverhaulTSN:    3000,
+		HSICost:        50000,
+		OverhaulCost:   100000,
+		TargetProfit:   3000000,
+		InitialRate:    320,
+	}
+
+	for _, method := range []string{financials.MethodHybrid, financials.MethodNewton, financials.MethodBrent} {
+		b.Run(method, func(b *testing.B) {
+			p := params
+			p.Solver.Method = method
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				engine := NewGoalSeekCalculator(p)
+				if err := engine.Compute(); err != nil {
+					b.Fatalf("Compute error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -52,4 +52,35 @@ func TestGoalSeekEngine(t *testing.T) {
 	if optimalRate < 300 || optimalRate > 600 {
 		t.Errorf("Expected optimalWarrantyRate between 300 and 600, got %f", optimalRate)
 	}
-}
\ No newline at end of file
+}
+
+func TestGoalSeekEngineSolveFor(t *testing.T) {
+	params := financials.FinancialParams{
+		NumYears:       10,
+		AuHours:        450,
+		InitialTSN:     100,
+		RateEscalation: 5,
+		AIC:            10,
+		HSITSN:         1000,
+		OverhaulTSN:    3000,
+		HSICost:        50000,
+		OverhaulCost:   100000,
+		TargetProfit:   3000000,
+		InitialRate:    505.93820432563325,
+		SolveFor:       "auHours",
+	}
+
+	engine := NewGoalSeekCalculator(params)
+	testutils.AssertNoError(t, engine.Validate())
+	testutils.AssertNoError(t, engine.Compute())
+
+	resultMap := engine.GetResult().(map[string]interface{})
+	testutils.AssertEqual(t, "auHours", resultMap["solveFor"])
+	testutils.AssertEqual(t, params.InitialRate, resultMap["warrantyRate"])
+
+	// At the rate that solves the sample request, the sample AuHours is the answer.
+	auHours := resultMap["solvedValue"].(float64)
+	if auHours < 449.99 || auHours > 450.01 {
+		t.Errorf("Expected solved auHours near 450, got %f", auHours)
+	}
+}
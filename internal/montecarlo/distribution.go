@@ -0,0 +1,82 @@
+package montecarlo
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// Distribution types accepted in Distribution.Type.
+const (
+	Normal     = "normal"
+	Triangular = "triangular"
+	Uniform    = "uniform"
+	LogNormal  = "lognormal"
+)
+
+// Distribution describes the uncertainty in one FinancialParams field, named
+// by its JSON name. Normal and lognormal use Mean and StdDev (for lognormal,
+// of the value itself rather than of its logarithm); uniform uses Min and
+// Max; triangular uses Min, Mode and Max.
+type Distribution struct {
+	Field  string  `json:"field"`
+	Type   string  `json:"type"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stdDev"`
+	Min    float64 `json:"min"`
+	Mode   float64 `json:"mode"`
+	Max    float64 `json:"max"`
+}
+
+func (d Distribution) Validate() error {
+	switch d.Type {
+	case Normal:
+		if d.StdDev < 0 {
+			return fmt.Errorf("stdDev for %s cannot be negative", d.Field)
+		}
+	case LogNormal:
+		if d.Mean <= 0 {
+			return fmt.Errorf("mean for lognormal %s must be positive", d.Field)
+		}
+		if d.StdDev < 0 {
+			return fmt.Errorf("stdDev for %s cannot be negative", d.Field)
+		}
+	case Uniform:
+		if d.Max < d.Min {
+			return fmt.Errorf("max for %s must not be below min", d.Field)
+		}
+	case Triangular:
+		if d.Mode < d.Min || d.Mode > d.Max {
+			return fmt.Errorf("mode for %s must be between min and max", d.Field)
+		}
+	default:
+		return fmt.Errorf("unknown distribution type %q for %s", d.Type, d.Field)
+	}
+	return nil
+}
+
+// Sample draws one value from the distribution.
+func (d Distribution) Sample(r *rand.Rand) float64 {
+	switch d.Type {
+	case Normal:
+		return d.Mean + d.StdDev*r.NormFloat64()
+	case LogNormal:
+		sigma2 := math.Log1p(d.StdDev * d.StdDev / (d.Mean * d.Mean))
+		mu := math.Log(d.Mean) - sigma2/2
+		return math.Exp(mu + math.Sqrt(sigma2)*r.NormFloat64())
+	case Uniform:
+		return d.Min + (d.Max-d.Min)*r.Float64()
+	case Triangular:
+		// Inverse CDF of the triangular distribution.
+		u := r.Float64()
+		width := d.Max - d.Min
+		if width == 0 {
+			return d.Min
+		}
+		if u < (d.Mode-d.Min)/width {
+			return d.Min + math.Sqrt(u*width*(d.Mode-d.Min))
+		}
+		return d.Max - math.Sqrt((1-u)*width*(d.Max-d.Mode))
+	}
+	return math.NaN()
+}
@@ -0,0 +1,250 @@
+package montecarlo
+
+import (
+	"errors"
+	"financialapi/internal/financials"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// Ensure MonteCarlo implements ComputeEngine
+var _ financials.ComputeEngine = (*MonteCarlo)(nil)
+
+const (
+	// MaxScenarios bounds the work a single request can ask for.
+	MaxScenarios = 100000
+	// DefaultBins is the histogram resolution when Bins is not set.
+	DefaultBins = 20
+	// MaxBins bounds the histogram a single request can ask for.
+	MaxBins = 1000
+	// maxResamples bounds how often a scenario is redrawn when a sample
+	// falls outside the valid range of its field.
+	maxResamples = 100
+)
+
+// ErrNoValidScenario is returned, wrapped with the number of draws, when the
+// distributions keep drawing scenarios outside the valid range of a field.
+var ErrNoValidScenario = errors.New("no valid scenario")
+
+// MonteCarloParams runs the profit model of Params at Params.InitialRate
+// over Scenarios sampled scenarios. Each Distribution replaces the value of
+// its field in every scenario. The same Seed always gives the same result.
+type MonteCarloParams struct {
+	Params        financials.FinancialParams `json:"params"`
+	Scenarios     int                        `json:"scenarios"`
+	Seed          uint64                     `json:"seed"`
+	Bins          int                        `json:"bins"`
+	Distributions []Distribution             `json:"distributions"`
+}
+
+func (p MonteCarloParams) Validate() error {
+	if err := p.Params.ValidateModel(); err != nil {
+		return err
+	}
+	if p.Params.InitialRate <= 0 {
+		return fmt.Errorf("InitialRate must be positive")
+	}
+	if p.Scenarios <= 0 || p.Scenarios > MaxScenarios {
+		return fmt.Errorf("Scenarios must be between 1 and %d", MaxScenarios)
+	}
+	if p.Bins < 0 || p.Bins > MaxBins {
+		return fmt.Errorf("Bins must be between 0 and %d", MaxBins)
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range p.Distributions {
+		params := p.Params
+		if _, err := params.Field(d.Field); err != nil {
+			return err
+		}
+		if seen[d.Field] {
+			return fmt.Errorf("more than one distribution for %s", d.Field)
+		}
+		seen[d.Field] = true
+		if err := d.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HistogramBin counts the scenarios with profit in [Lower, Upper); the last
+// bin also includes its upper bound.
+type HistogramBin struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+}
+
+// Result summarises the cumulative profit over all scenarios.
+type Result struct {
+	Scenarios         int            `json:"scenarios"`
+	Mean              float64        `json:"mean"`
+	StdDev            float64        `json:"stdDev"`
+	Min               float64        `json:"min"`
+	P10               float64        `json:"p10"`
+	P50               float64        `json:"p50"`
+	P90               float64        `json:"p90"`
+	Max               float64        `json:"max"`
+	ProbabilityOfLoss float64        `json:"probabilityOfLoss"`
+	Histogram         []HistogramBin `json:"histogram"`
+}
+
+type MonteCarlo struct {
+	Params MonteCarloParams
+	result Result
+}
+
+func (mc *MonteCarlo) Initialize(params interface{}) error {
+	if p, ok := params.(MonteCarloParams); ok {
+		mc.Params = p
+		return nil
+	}
+	return fmt.Errorf("invalid params type for MonteCarlo")
+}
+
+func (mc *MonteCarlo) Validate() error {
+	return mc.Params.Validate()
+}
+
+func (mc *MonteCarlo) Compute() error {
+	result, err := Simulate(mc.Params)
+	if err != nil {
+		return err
+	}
+	mc.result = result
+	return nil
+}
+
+func (mc *MonteCarlo) GetResult() interface{} {
+	return mc.result
+}
+
+// NewMonteCarloCalculator creates a new MonteCarlo instance
+func NewMonteCarloCalculator(params MonteCarloParams) financials.ComputeEngine {
+	mc := &MonteCarlo{}
+	mc.Initialize(params)
+	return mc
+}
+
+// Simulate samples the scenarios and summarises their cumulative profit.
+func Simulate(params MonteCarloParams) (Result, error) {
+	if err := params.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	r := rand.New(rand.NewPCG(params.Seed, params.Seed))
+	profits := make([]float64, params.Scenarios)
+	for i := range profits {
+		scenario, err := sampleScenario(params, r)
+		if err != nil {
+			return Result{}, err
+		}
+		profit, err := financials.CalculateFinancials(scenario.InitialRate, scenario)
+		if err != nil {
+			return Result{}, err
+		}
+		profits[i] = profit
+	}
+
+	bins := params.Bins
+	if bins == 0 {
+		bins = DefaultBins
+	}
+	return summarize(profits, bins), nil
+}
+
+// sampleScenario draws a value for every distribution. A scenario that
+// fails model validation, such as a negative AuHours drawn from a normal
+// distribution, is redrawn, which truncates the distributions to the valid
+// range.
+func sampleScenario(params MonteCarloParams, r *rand.Rand) (financials.FinancialParams, error) {
+	for attempt := 0; attempt < maxResamples; attempt++ {
+		scenario := params.Params
+		for _, d := range params.Distributions {
+			field, err := scenario.Field(d.Field)
+			if err != nil {
+				return financials.FinancialParams{}, err
+			}
+			*field = d.Sample(r)
+		}
+		if err := scenario.ValidateModel(); err == nil {
+			return scenario, nil
+		}
+	}
+	return financials.FinancialParams{}, fmt.Errorf("%w in %d draws, narrow the distributions to the valid range of their fields", ErrNoValidScenario, maxResamples)
+}
+
+func summarize(profits []float64, bins int) Result {
+	sorted := append([]float64(nil), profits...)
+	sort.Float64s(sorted)
+	n := float64(len(sorted))
+
+	var sum, losses float64
+	for _, p := range sorted {
+		sum += p
+		if p < 0 {
+			losses++
+		}
+	}
+	mean := sum / n
+
+	var squares float64
+	for _, p := range sorted {
+		squares += (p - mean) * (p - mean)
+	}
+	stdDev := 0.0
+	if len(sorted) > 1 {
+		stdDev = math.Sqrt(squares / (n - 1))
+	}
+
+	return Result{
+		Scenarios:         len(sorted),
+		Mean:              mean,
+		StdDev:            stdDev,
+		Min:               sorted[0],
+		P10:               percentile(sorted, 0.1),
+		P50:               percentile(sorted, 0.5),
+		P90:               percentile(sorted, 0.9),
+		Max:               sorted[len(sorted)-1],
+		ProbabilityOfLoss: losses / n,
+		Histogram:         histogram(sorted, bins),
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of sorted.
+func percentile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
+
+func histogram(sorted []float64, bins int) []HistogramBin {
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		return []HistogramBin{{Lower: lo, Upper: hi, Count: len(sorted)}}
+	}
+
+	width := (hi - lo) / float64(bins)
+	hist := make([]HistogramBin, bins)
+	for i := range hist {
+		hist[i].Lower = lo + float64(i)*width
+		hist[i].Upper = lo + float64(i+1)*width
+	}
+	hist[bins-1].Upper = hi
+
+	for _, p := range sorted {
+		i := int((p - lo) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		hist[i].Count++
+	}
+	return hist
+}
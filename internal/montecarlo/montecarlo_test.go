@@ -0,0 +1,159 @@
+// File: internal/montecarlo/montecarlo_test.go
+
+package montecarlo
+
+import (
+	"financialapi/internal/financials"
+	"financialapi/pkg/testutils"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func getTestParams() MonteCarloParams {
+	return MonteCarloParams{
+		Params: financials.FinancialParams{
+			NumYears:       10,
+			AuHours:        450,
+			InitialTSN:     100,
+			RateEscalation: 5,
+			AIC:            10,
+			HSITSN:         1000,
+			OverhaulTSN:    3000,
+			HSICost:        50000,
+			OverhaulCost:   100000,
+			InitialRate:    320,
+		},
+		Scenarios: 5000,
+		Seed:      42,
+		Distributions: []Distribution{
+			{Field: "auHours", Type: Normal, Mean: 450, StdDev: 60},
+			{Field: "overhaulTSN", Type: Triangular, Min: 2500, Mode: 3000, Max: 3600},
+			{Field: "overhaulCost", Type: LogNormal, Mean: 100000, StdDev: 30000},
+			{Field: "hsiCost", Type: Uniform, Min: 40000, Max: 70000},
+		},
+	}
+}
+
+func TestMonteCarloEngine(t *testing.T) {
+	params := getTestParams()
+
+	engine := NewMonteCarloCalculator(params)
+	testutils.AssertNoError(t, engine.Initialize(params))
+	testutils.AssertNoError(t, engine.Validate())
+	testutils.AssertNoError(t, engine.Compute())
+
+	result, ok := engine.GetResult().(Result)
+	if !ok {
+		t.Fatalf("GetResult did not return a Result")
+	}
+
+	testutils.AssertEqual(t, params.Scenarios, result.Scenarios)
+	if !(result.Min <= result.P10 && result.P10 <= result.P50 && result.P50 <= result.P90 && result.P90 <= result.Max) {
+		t.Errorf("Percentiles out of order: %+v", result)
+	}
+	if result.StdDev <= 0 {
+		t.Errorf("Expected positive standard deviation, got %f", result.StdDev)
+	}
+
+	// Utilization is centred on the base case, so the mean profit should be close to it.
+	base, err := financials.CalculateFinancials(params.Params.InitialRate, params.Params)
+	testutils.AssertNoError(t, err)
+	if math.Abs(result.Mean-base)/base > 0.05 {
+		t.Errorf("Expected mean profit near the base case %f, got %f", base, result.Mean)
+	}
+
+	total := 0
+	for _, bin := range result.Histogram {
+		total += bin.Count
+	}
+	testutils.AssertEqual(t, DefaultBins, len(result.Histogram))
+	testutils.AssertEqual(t, params.Scenarios, total)
+
+	// The same seed reproduces the result exactly.
+	again, err := Simulate(params)
+	testutils.AssertNoError(t, err)
+	testutils.AssertEqual(t, result.Mean, again.Mean)
+	testutils.AssertEqual(t, result.P90, again.P90)
+
+	params.Seed = 7
+	other, err := Simulate(params)
+	testutils.AssertNoError(t, err)
+	if other.Mean == result.Mean {
+		t.Errorf("Expected a different seed to give a different mean")
+	}
+}
+
+func TestMonteCarloProbabilityOfLoss(t *testing.T) {
+	params := getTestParams()
+	params.Scenarios = 2000
+	params.Distributions = []Distribution{
+		{Field: "overhaulCost", Type: Uniform, Min: 0, Max: 4000000},
+	}
+
+	result, err := Simulate(params)
+	testutils.AssertNoError(t, err)
+
+	// Profit before the overhaul is about 2M, so roughly half the scenarios lose money.
+	if result.ProbabilityOfLoss < 0.4 || result.ProbabilityOfLoss > 0.6 {
+		t.Errorf("Expected probability of loss near 0.5, got %f", result.ProbabilityOfLoss)
+	}
+}
+
+func TestMonteCarloValidate(t *testing.T) {
+	params := getTestParams()
+	params.Distributions = append(params.Distributions, Distribution{Field: "auHours", Type: Uniform, Min: 1, Max: 2})
+	testutils.AssertError(t, params.Validate())
+
+	params = getTestParams()
+	params.Distributions = []Distribution{{Field: "numYears", Type: Uniform, Min: 1, Max: 2}}
+	testutils.AssertError(t, params.Validate())
+
+	params = getTestParams()
+	params.Distributions = []Distribution{{Field: "auHours", Type: "beta"}}
+	testutils.AssertError(t, params.Validate())
+
+	params = getTestParams()
+	params.Scenarios = 0
+	testutils.AssertError(t, params.Validate())
+
+	params = getTestParams()
+	params.Bins = MaxBins + 1
+	testutils.AssertError(t, params.Validate())
+	params.Bins = MaxBins
+	testutils.AssertNoError(t, params.Validate())
+}
+
+func TestDistributionSample(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	const n = 20000
+
+	tests := []struct {
+		d            Distribution
+		mean, stdDev float64
+	}{
+		{Distribution{Type: Normal, Mean: 10, StdDev: 2}, 10, 2},
+		{Distribution{Type: LogNormal, Mean: 10, StdDev: 2}, 10, 2},
+		{Distribution{Type: Uniform, Min: 0, Max: 12}, 6, math.Sqrt(12)},
+		{Distribution{Type: Triangular, Min: 0, Mode: 3, Max: 9}, 4, math.Sqrt((81 + 9 - 27) / 18.0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.d.Type, func(t *testing.T) {
+			var sum, squares float64
+			for i := 0; i < n; i++ {
+				v := tt.d.Sample(r)
+				sum += v
+				squares += v * v
+			}
+			mean := sum / n
+			stdDev := math.Sqrt(squares/n - mean*mean)
+			if math.Abs(mean-tt.mean) > 0.1 {
+				t.Errorf("Expected mean %f, got %f", tt.mean, mean)
+			}
+			if math.Abs(stdDev-tt.stdDev) > 0.1 {
+				t.Errorf("Expected standard deviation %f, got %f", tt.stdDev, stdDev)
+			}
+		})
+	}
+}
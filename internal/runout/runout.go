@@ -1,8 +1,11 @@
 package runout
 
 import (
+	"financialapi/internal/civil"
 	"financialapi/internal/financials"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 )
 
@@ -50,46 +53,70 @@ func NewRunoutCalculator(params RunoutParams) financials.ComputeEngine {
 Main calculation begins
 */
 type EngineData struct {
-	EngineID          int
-	WarrantyRateDays  int
-	FirstRunRateDays  int
-	SecondRunRateDays int
-	ThirdRunRateDays  int
-	TotalDays         int
-	FHUtilization     float64
-	FHRevenue         float64
-	WarrantyCalc      float64
-	FirstRunRateCalc  float64
-	SecondRunRateCalc float64
-	ThirdRunRateCalc  float64
-	Rates             float64
-	EscalatedRate     float64
-	Shortfall         float64
+	EngineID         int
+	SerialNumber     string
+	Position         int
+	Model            string
+	Active           []ActiveWindow
+	Tiers            []TierData
+	TotalDays        int
+	FHUtilization    float64
+	FHRevenue        float64
+	Rates            float64
+	EscalatedRate    float64
+	Shortfall        float64
+	ShortfallRevenue float64
+	BuyIn            float64
+
+	// activeShare is the part of the runout the engine is enrolled for.
+	activeShare float64
 }
 
 type ContractPeriod struct {
-	StartDate              time.Time
-	EndDate                time.Time
+	StartDate              civil.Date
+	EndDate                civil.Date
 	NumOfDays              int
-	RunoutStartDate        time.Time
-	RunoutEndDate          time.Time
+	RunoutStartDate        civil.Date
+	RunoutEndDate          civil.Date
 	NumOfRunoutDays        int
+	YearFraction           float64
 	ContractYearNumber     int
 	RateTrend              float64
 	Engines                []EngineData
 	TotalFHRevenue         float64
+	ShortfallRevenue       float64
+	Waterfall              []FeeLine
 	MgmtFeeRevenue         float64
 	AICRevenue             float64
 	TrustLoadRevenue       float64
 	TrustRevenue           float64
 	TotalRevenue           float64
 	BuyIn                  float64
+	TerminationFee         float64
 	CumulativeTotalRevenue float64
 }
 
+// EngineSummary reports, for each engine, the windows it is enrolled for,
+// the utilization and minimum hours in effect for it, and the day each of
+// its rate tiers starts and what triggered it.
+type EngineSummary struct {
+	SerialNumber       string
+	Position           int
+	Model              string
+	Active             []ActiveWindow
+	AUHours            float64
+	FlightHoursMinimum float64
+	Tiers              []TierSummary
+}
+
 type RunoutResult struct {
 	Periods                []ContractPeriod
+	Engines                []EngineSummary
+	MonthlySchedule        []ContractPeriod
+	TrueUp                 *TrueUp
 	TotalFHRevenue         float64
+	ShortfallRevenue       float64
+	Waterfall              []FeeLine
 	MgmtFeeRevenue         float64
 	AICRevenue             float64
 	TrustLoadRevenue       float64
@@ -97,155 +124,394 @@ type RunoutResult struct {
 	TotalRevenue           float64
 	EnrollmentFees         float64
 	BuyIn                  float64
+	TerminationFee         float64
 	CumulativeTotalRevenue float64
 }
 
-var rateTrendValues = []float64{
-	1, 1.0875, 1.18265625, 1.286138671875, 1.39867580566406, 1.52105993865967,
-	1.65415268329239, 1.79889104308047, 1.95629400935001, 2.12746973516814,
-	2.31362333699535, 2.51606537898244, 2.73622109964341, 2.97564044586221,
-	3.23600898487515, 3.51915977105172, 3.82708625101875, 4.16195629798289,
-	4.52612747405639, 4.92216362803633, 5.3528529454895, 5.82122757821983,
-	6.33058499131407, 6.88451117805405, 7.48690590613378, 8.14201017292048,
-	8.85443606305102, 9.62919921856799, 10.4717541501927, 11.3880326383345,
-	12.3844854941888, 13.4681279749303, 14.6465891727367, 15.9281657253512,
-	17.3218802263194, 18.8375447461224,
+// rateTrends returns the rate trend of each of the first numPeriods contract
+// years: 1 in year 1, compounding by the escalation of every year after it.
+func rateTrends(params RunoutParams, numPeriods int) []float64 {
+	trends := make([]float64, numPeriods)
+	trend := 1.0
+	for i := range trends {
+		if i > 0 {
+			trend *= 1 + params.EscalationAfterYear(i)/100
+		}
+		trends[i] = trend
+	}
+	return trends
 }
 
-var engineValues = []int{1085718, 1085719}
-
 func Calculate(params RunoutParams) (RunoutResult, error) {
+	return calculate(params, params.ContractEndDate)
+}
+
+// calculate runs the runout of a contract agreed to end on originalEnd,
+// which an early termination brings forward to params.ContractEndDate. The
+// contract years are those of the original term, and the buy-in is
+// amortized over it.
+func calculate(params RunoutParams, originalEnd civil.Date) (RunoutResult, error) {
 	if err := params.Validate(); err != nil {
 		return RunoutResult{}, err
 	}
 
-	periods := calculateContractPeriods(params.ContractStartDate, params.ContractEndDate)
+	actuals, err := params.AllActuals()
+	if err != nil {
+		return RunoutResult{}, err
+	}
+	byEngine := actualsByEngine(params, actuals)
+
+	periods := calculateContractPeriods(params, originalEnd)
 
 	result := RunoutResult{
 		Periods:        periods,
 		EnrollmentFees: params.EnrollmentFees,
-		BuyIn:          params.BuyIn,
 	}
 
-	numPeriods := len(periods)
-	if numPeriods > len(rateTrendValues) {
-		numPeriods = len(rateTrendValues)
+	result.Engines = make([]EngineSummary, len(params.EngineParams))
+	for e, engineParams := range params.EngineParams {
+		result.Engines[e] = EngineSummary{
+			SerialNumber:       engineParams.SerialNumber,
+			Position:           engineParams.Position,
+			Model:              engineParams.Model,
+			Active:             activeWindows(params, engineParams),
+			AUHours:            params.EngineAUHours(engineParams),
+			FlightHoursMinimum: params.EngineFlightHoursMinimum(engineParams),
+			Tiers:              tierStarts(params, engineParams, byEngine),
+		}
 	}
 
-	for i := 0; i < numPeriods; i++ {
-		result.Periods[i].Engines = make([]EngineData, len(engineValues))
-		calculatePeriodDetails(&result.Periods[i], params, i+1, rateTrendValues[i])
-		for e, engineValue := range engineValues {
-			calculateEngineRevenue(&result.Periods[i], params, e, engineValue)
+	trends := rateTrends(params, len(periods))
+
+	for i := range periods {
+		result.Periods[i].Engines = make([]EngineData, len(params.EngineParams))
+		calculatePeriodDetails(&result.Periods[i], params, result.Engines, i+1, trends[i])
+		for e := range params.EngineParams {
+			calculateEngineRevenue(&result.Periods[i], params, byEngine, e)
 		}
 		result.Periods[i].TotalFHRevenue = sumEngineFHRevenue(result.Periods[i].Engines)
 		result.TotalFHRevenue += result.Periods[i].TotalFHRevenue
 	}
 
+	calculateShortfallRevenue(&result, params)
+	allocateBuyIn(&result, params, originalEnd)
 	calculateTotalRevenues(&result, params)
+	if params.IncludeMonthlySchedule {
+		calculateMonthlySchedule(&result, params, byEngine)
+	}
+	if len(actuals) > 0 {
+		if err := calculateTrueUp(&result, params, originalEnd, actuals, byEngine); err != nil {
+			return RunoutResult{}, err
+		}
+	}
 
 	return result, nil
 }
 
-func calculateContractPeriods(startDate, endDate time.Time) []ContractPeriod {
+// minPeriodDays is the length below which a first or last period is a stub.
+const minPeriodDays = 300
+
+// calculateContractPeriods splits the contract into the periods it bills.
+// A contract ended before originalEnd bills its last part year whenever the
+// original contract would have billed that year, so a year is a stub only
+// when it is one of the original term.
+func calculateContractPeriods(params RunoutParams, originalEnd civil.Date) []ContractPeriod {
 	periods := []ContractPeriod{}
-	currentDate := startDate
-	yearNumber := 1
-
-	for currentDate.Before(endDate) || currentDate.Equal(endDate) {
-		var periodEnd time.Time
-		if currentDate.Day() <= 14 {
-			periodEnd = time.Date(currentDate.Year()+1, currentDate.Month(), 1, 0, 0, 0, 0, currentDate.Location()).Add(-time.Second)
-		} else {
-			periodEnd = time.Date(currentDate.Year()+1, currentDate.Month()+1, 1, 0, 0, 0, 0, currentDate.Location()).Add(-time.Second)
-			periodEnd = periodEnd.AddDate(0, 0, -periodEnd.Day())
+	merged := []bool{}
+	var mergeStart civil.Date
+	pendingMerge := false
+
+	segments := contractSegments(params)
+	original := segments
+	if originalEnd != params.ContractEndDate {
+		p := params
+		p.ContractEndDate = originalEnd
+		original = contractSegments(p)
+	}
+	for i, segment := range segments {
+		isMerged := false
+		if pendingMerge {
+			segment.StartDate = mergeStart
+			pendingMerge = false
+			isMerged = true
+		} else if daysBetween(original[i].StartDate, original[i].EndDate) < minPeriodDays {
+			switch params.StubHandling {
+			case StubProrate:
+			case StubMerge:
+				if len(periods) > 0 {
+					periods[len(periods)-1].EndDate = segment.EndDate
+					merged[len(merged)-1] = true
+					continue
+				}
+				if i+1 < len(segments) {
+					mergeStart = segment.StartDate
+					pendingMerge = true
+					continue
+				}
+				// A contract shorter than a stub has nothing to merge with.
+			default:
+				continue
+			}
 		}
+		periods = append(periods, segment)
+		merged = append(merged, isMerged)
+	}
 
-		if periodEnd.After(endDate) {
-			periodEnd = endDate
-		}
+	for i := range periods {
+		period := &periods[i]
+		period.NumOfDays = daysBetween(period.StartDate, period.EndDate)
+		period.ContractYearNumber = i + 1
 
-		runoutStart := periodEnd.AddDate(-1, 0, 1)
-		if runoutStart.Before(currentDate) {
-			runoutStart = currentDate
+		// The runout covers the last year of the period, or all of it when
+		// a stub was merged in.
+		runoutStart := period.EndDate.AddDate(-1, 0, 1)
+		if runoutStart.Before(period.StartDate) || merged[i] {
+			runoutStart = period.StartDate
 		}
+		period.RunoutStartDate = runoutStart
+		period.RunoutEndDate = period.EndDate
+		period.NumOfRunoutDays = daysBetween(runoutStart, period.EndDate)
+		period.YearFraction = params.YearFraction(runoutStart, period.EndDate)
+	}
+
+	return periods
+}
 
-		days := int(periodEnd.Sub(currentDate).Hours()/24) + 1
-		if days >= 300 {
-			period := ContractPeriod{
-				StartDate:          currentDate,
-				EndDate:            periodEnd,
-				NumOfDays:          days,
-				RunoutStartDate:    runoutStart,
-				RunoutEndDate:      periodEnd,
-				NumOfRunoutDays:    int(periodEnd.Sub(runoutStart).Hours()/24) + 1,
-				ContractYearNumber: yearNumber,
+// contractSegments splits the contract into consecutive periods according to
+// params.PeriodPolicy, before any stub handling.
+func contractSegments(params RunoutParams) []ContractPeriod {
+	segments := []ContractPeriod{}
+	start := params.ContractStartDate
+	currentDate := start
+
+	for !currentDate.After(params.ContractEndDate) {
+		var periodEnd civil.Date
+		switch params.PeriodPolicy {
+		case PeriodAnniversary:
+			periodEnd = civil.New(start.Year+len(segments)+1, start.Month, start.Day).AddDays(-1)
+		case PeriodCalendar:
+			periodEnd = civil.New(currentDate.Year, time.December, 31)
+		case PeriodFiscal:
+			boundary := civil.New(currentDate.Year, time.Month(params.FiscalYearStartMonth), 1)
+			if !boundary.After(currentDate) {
+				boundary = boundary.AddDate(1, 0, 0)
 			}
-			periods = append(periods, period)
-			yearNumber++
+			periodEnd = boundary.AddDays(-1)
+		default:
+			if currentDate.Day <= 14 {
+				periodEnd = civil.New(currentDate.Year+1, currentDate.Month, 1).AddDays(-1)
+			} else {
+				periodEnd = civil.New(currentDate.Year+1, currentDate.Month+1, 1).AddDays(-1)
+				periodEnd = periodEnd.AddDays(-periodEnd.Day)
+			}
+		}
+
+		if periodEnd.After(params.ContractEndDate) {
+			periodEnd = params.ContractEndDate
 		}
 
-		currentDate = periodEnd.AddDate(0, 0, 1)
+		segments = append(segments, ContractPeriod{StartDate: currentDate, EndDate: periodEnd})
+		currentDate = periodEnd.AddDays(1)
 	}
 
-	return periods
+	return segments
 }
 
-func calculatePeriodDetails(period *ContractPeriod, params RunoutParams, yearNumber int, rateTrend float64) {
+// engineID returns a numeric serial number as the EngineID runout has always
+// reported, and 0 for any other serial number.
+func engineID(serialNumber string) int {
+	id, err := strconv.Atoi(serialNumber)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// daysBetween counts the days from start to end, both included.
+func daysBetween(start, end civil.Date) int {
+	return end.DaysSince(start) + 1
+}
+
+func calculatePeriodDetails(period *ContractPeriod, params RunoutParams, summaries []EngineSummary, yearNumber int, rateTrend float64) {
 	period.RateTrend = rateTrend
 
-	for e, engineValue := range engineValues {
+	for e, engineParams := range params.EngineParams {
 		engine := &period.Engines[e]
-		engine.EngineID = engineValue
-		engineParams := params.EngineParams[e]
+		engine.EngineID = engineID(engineParams.SerialNumber)
+		engine.SerialNumber = engineParams.SerialNumber
+		engine.Position = engineParams.Position
+		engine.Model = engineParams.Model
+		engine.Active = clipWindows(summaries[e].Active, period.RunoutStartDate, period.RunoutEndDate)
+		engine.activeShare = activeShare(params, *period, engine.Active)
+
+		// Each tier runs until the day before the next one starts; the last
+		// runs to the end of the period. Only the days the engine is
+		// enrolled count.
+		tiers := summaries[e].Tiers
+		engine.Tiers = make([]TierData, len(tiers))
+		engine.TotalDays = 0
+		for i, tier := range tiers {
+			tierEnd := period.RunoutEndDate
+			if i+1 < len(tiers) {
+				tierEnd = tiers[i+1].StartDate.AddDays(-1)
+			}
+			data := &engine.Tiers[i]
+			data.Name = tier.Name
+			data.Rate = tier.Rate
+			for _, window := range engine.Active {
+				start, end := civil.Max(tier.StartDate, window.StartDate), civil.Min(tierEnd, window.EndDate)
+				if !end.Before(start) {
+					data.Days += params.CountDays(start, end)
+					data.windows = append(data.windows, ActiveWindow{StartDate: start, EndDate: end})
+				}
+			}
+			engine.TotalDays += data.Days
+		}
+	}
+}
+
+func calculateEngineRevenue(period *ContractPeriod, params RunoutParams, actuals monthlyActuals, engineIndex int) {
+	engine := &period.Engines[engineIndex]
+	engineParams := params.EngineParams[engineIndex]
+
+	// Flight hours are the actual hours where there are actuals, and accrue
+	// at the engine's AUHours a year, measured in the day-count convention's
+	// years, elsewhere. Each tier's hours are billed at its rate.
+	engine.Rates = 0
+	engine.FHUtilization = 0
+	engine.FHRevenue = 0
+	for i := range engine.Tiers {
+		tier := &engine.Tiers[i]
+		tier.Calc = float64(tier.Days) * tier.Rate
+		tier.FlightHours = engineFlightHours(params, engineParams, actuals, tier.windows)
+		tier.FHRevenue = tier.FlightHours * tier.Rate * period.RateTrend
+
+		engine.Rates += tier.Calc
+		engine.FHUtilization += tier.FlightHours
+		engine.FHRevenue += tier.FHRevenue
+	}
+	engine.EscalatedRate = engine.Rates * period.RateTrend
 
-		engine.WarrantyRateDays = calculateDaysWithinPeriod(period.RunoutStartDate, engineParams.WarrantyExpDate, period.RunoutStartDate, period.RunoutEndDate)
-		engine.FirstRunRateDays = calculateDaysWithinPeriod(engineParams.WarrantyExpDate.AddDate(0, 0, 1), engineParams.FirstRunRateSwitchDate, period.RunoutStartDate, period.RunoutEndDate)
-		engine.SecondRunRateDays = calculateDaysWithinPeriod(engineParams.FirstRunRateSwitchDate.AddDate(0, 0, 1), engineParams.SecondRunRateSwitchDate, period.RunoutStartDate, period.RunoutEndDate)
+	if minimum := engineFlightHoursMinimum(*period, *engine, params.EngineFlightHoursMinimum(engineParams)); engine.FHUtilization < minimum {
+		engine.Shortfall = minimum - engine.FHUtilization
+	}
+}
 
-		if period.RunoutEndDate.After(engineParams.ThirdRunRateSwitchDate) {
-			engine.ThirdRunRateDays = calculateDaysWithinPeriod(engineParams.SecondRunRateSwitchDate.AddDate(0, 0, 1), period.RunoutEndDate, period.RunoutStartDate, period.RunoutEndDate)
-		} else {
-			engine.ThirdRunRateDays = calculateDaysWithinPeriod(engineParams.SecondRunRateSwitchDate.AddDate(0, 0, 1), engineParams.ThirdRunRateSwitchDate, period.RunoutStartDate, period.RunoutEndDate)
+// calculateShortfallRevenue bills shortfall hours at the engine's average
+// escalated rate per flight hour in the contract year they are billed.
+func calculateShortfallRevenue(result *RunoutResult, params RunoutParams) {
+	switch params.ShortfallBilling {
+	case ShortfallAnnual:
+		for i := range result.Periods {
+			for e := range result.Periods[i].Engines {
+				engine := &result.Periods[i].Engines[e]
+				engine.ShortfallRevenue = engine.Shortfall * hourlyRate(*engine)
+			}
+		}
+	case ShortfallEndOfTerm:
+		if len(result.Periods) == 0 {
+			return
 		}
+		for e, engineParams := range params.EngineParams {
+			minimum := params.EngineFlightHoursMinimum(engineParams)
+			carried, outstanding := 0.0, 0.0
+			last := -1
+			for i := range result.Periods {
+				engine := result.Periods[i].Engines[e]
+				if engine.TotalDays > 0 {
+					last = i
+				}
+				excess := engine.FHUtilization - engineFlightHoursMinimum(result.Periods[i], engine, minimum)
+				if excess >= 0 {
+					carried += excess
+					continue
+				}
+				offset := math.Min(carried, -excess)
+				carried -= offset
+				outstanding += -excess - offset
+			}
+			// The shortfall is billed in the last year the engine is
+			// enrolled, at its rate in that year.
+			if last >= 0 {
+				engine := &result.Periods[last].Engines[e]
+				engine.ShortfallRevenue = outstanding * hourlyRate(*engine)
+			}
+		}
+	default:
+		return
+	}
 
-		engine.TotalDays = engine.WarrantyRateDays + engine.FirstRunRateDays + engine.SecondRunRateDays + engine.ThirdRunRateDays
+	for i := range result.Periods {
+		period := &result.Periods[i]
+		period.ShortfallRevenue = 0
+		for _, engine := range period.Engines {
+			period.ShortfallRevenue += engine.ShortfallRevenue
+		}
+		result.ShortfallRevenue += period.ShortfallRevenue
 	}
 }
 
-func calculateEngineRevenue(period *ContractPeriod, params RunoutParams, engineIndex, engineValue int) {
-	engine := &period.Engines[engineIndex]
+// flightHoursMinimum returns the minimum hours for the period from an
+// engine's annual minimum.
+func flightHoursMinimum(period ContractPeriod, annual float64) float64 {
+	return annual * periodShare(period)
+}
 
-	engine.WarrantyCalc = float64(engine.WarrantyRateDays) * params.WarrantyRate
-	engine.FirstRunRateCalc = float64(engine.FirstRunRateDays) * params.FirstRunRate
-	engine.SecondRunRateCalc = float64(engine.SecondRunRateDays) * params.SecondRunRate
-	engine.ThirdRunRateCalc = float64(engine.ThirdRunRateDays) * params.ThirdRunRate
+// periodShare returns the part of an annual amount that falls in the
+// period: all of it for a contract year, pro-rated by its YearFraction for a
+// stub or a period with a stub merged in.
+func periodShare(period ContractPeriod) float64 {
+	if period.NumOfRunoutDays >= minPeriodDays && period.NumOfRunoutDays <= 366 {
+		return 1
+	}
+	return period.YearFraction
+}
 
-	engine.Rates = engine.WarrantyCalc + engine.FirstRunRateCalc + engine.SecondRunRateCalc + engine.ThirdRunRateCalc
-	engine.EscalatedRate = engine.Rates * period.RateTrend
-	engine.FHUtilization = params.AUHours / params.NumOfDaysInYear * float64(engine.TotalDays)
+// engineFlightHoursMinimum returns an engine's minimum hours for the period,
+// pro-rated to the part of the runout it is enrolled for.
+func engineFlightHoursMinimum(period ContractPeriod, engine EngineData, annual float64) float64 {
+	return flightHoursMinimum(period, annual) * engine.activeShare
+}
 
-	if engine.FHUtilization < params.FlightHoursMinimum {
-		engine.Shortfall = params.FlightHoursMinimum - engine.FHUtilization
+// hourlyRate is the engine's escalated rate per flight hour averaged over
+// its days in the period.
+func hourlyRate(engine EngineData) float64 {
+	if engine.TotalDays == 0 {
+		return 0
 	}
-
-	engine.FHRevenue = engine.EscalatedRate * (params.AUHours / params.NumOfDaysInYear)
+	return engine.EscalatedRate / float64(engine.TotalDays)
 }
 
+// calculateTotalRevenues takes the fee waterfall and the buy-in from each
+// period's gross revenue and leaves the rest to the trust. The total revenue
+// is the gross revenue.
 func calculateTotalRevenues(result *RunoutResult, params RunoutParams) {
+	fees := feeWaterfall(params)
+	result.Waterfall = make([]FeeLine, len(fees))
+	for i, fee := range fees {
+		result.Waterfall[i].Name = fee.Name
+	}
+
 	cumulativeRevenue := 0.0
 	for i := range result.Periods {
 		period := &result.Periods[i]
-		period.MgmtFeeRevenue = period.TotalFHRevenue * (params.ManagementFees / 100)
-		period.AICRevenue = period.TotalFHRevenue - (1-params.ManagementFees/100)*(params.AICFees/100)
-		period.TrustLoadRevenue = period.TotalFHRevenue - (1-params.ManagementFees/100)*(params.TrustLoadFees/100)
-
-		if i == 0 {
-			period.BuyIn = params.BuyIn
+		period.Waterfall = applyFeeWaterfall(*period, fees)
+		period.MgmtFeeRevenue = feeAmount(period.Waterfall, FeeManagement)
+		period.AICRevenue = feeAmount(period.Waterfall, FeeAIC)
+		period.TrustLoadRevenue = feeAmount(period.Waterfall, FeeTrustLoad)
+
+		period.BuyIn = 0
+		for _, engine := range period.Engines {
+			period.BuyIn += engine.BuyIn
 		}
 
-		period.TrustRevenue = period.TotalFHRevenue - (period.MgmtFeeRevenue + period.AICRevenue + period.TrustLoadRevenue + period.BuyIn)
-		period.TotalRevenue = period.MgmtFeeRevenue + period.AICRevenue + period.TrustLoadRevenue + period.BuyIn + period.TrustRevenue
+		period.TrustRevenue = period.TotalFHRevenue + period.ShortfallRevenue - period.BuyIn
+		for j, line := range period.Waterfall {
+			period.TrustRevenue -= line.Amount
+			result.Waterfall[j].Base += line.Base
+			result.Waterfall[j].Amount += line.Amount
+		}
+		period.TotalRevenue = period.TotalFHRevenue + period.ShortfallRevenue
 
 		cumulativeRevenue += period.TotalRevenue
 		period.CumulativeTotalRevenue = cumulativeRevenue
@@ -253,6 +519,7 @@ func calculateTotalRevenues(result *RunoutResult, params RunoutParams) {
 		result.MgmtFeeRevenue += period.MgmtFeeRevenue
 		result.AICRevenue += period.AICRevenue
 		result.TrustLoadRevenue += period.TrustLoadRevenue
+		result.BuyIn += period.BuyIn
 		result.TrustRevenue += period.TrustRevenue
 		result.TotalRevenue += period.TotalRevenue
 	}
@@ -260,15 +527,6 @@ func calculateTotalRevenues(result *RunoutResult, params RunoutParams) {
 	result.CumulativeTotalRevenue = cumulativeRevenue
 }
 
-func calculateDaysWithinPeriod(start, end, periodStart, periodEnd time.Time) int {
-	if start.After(periodEnd) || end.Before(periodStart) {
-		return 0
-	}
-	actualStart := max(start, periodStart)
-	actualEnd := min(end, periodEnd)
-	return int(actualEnd.Sub(actualStart).Hours()/24) + 1
-}
-
 func sumEngineFHRevenue(engines []EngineData) float64 {
 	total := 0.0
 	for _, engine := range engines {
@@ -276,17 +534,3 @@ func sumEngineFHRevenue(engines []EngineData) float64 {
 	}
 	return total
 }
-
-func max(a, b time.Time) time.Time {
-	if a.After(b) {
-		return a
-	}
-	return b
-}
-
-func min(a, b time.Time) time.Time {
-	if a.Before(b) {
-		return a
-	}
-	return b
-}
@@ -3,15 +3,15 @@
 package runout
 
 import (
+	"financialapi/internal/civil"
 	"fmt"
 	"testing"
-	"time"
 )
 
 func getTestParams() RunoutParams {
 	return RunoutParams{
-		ContractStartDate:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
-		ContractEndDate:    time.Date(2034, 12, 31, 23, 59, 59, 0, time.UTC),
+		ContractStartDate:  civil.New(2023, 1, 1),
+		ContractEndDate:    civil.New(2034, 12, 31),
 		AUHours:            480,
 		WarrantyRate:       243.6,
 		FirstRunRate:       255.13,
@@ -29,18 +29,22 @@ func getTestParams() RunoutParams {
 		NumEngines:         2,
 		EngineParams: []EngineParams{
 			{
-				WarrantyExpDate:         time.Date(2025, 10, 31, 23, 59, 59, 0, time.UTC),
+				SerialNumber:            "1085718",
+				Position:                1,
+				WarrantyExpDate:         civil.New(2025, 10, 31),
 				WarrantyExpHours:        1000,
-				FirstRunRateSwitchDate:  time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
-				SecondRunRateSwitchDate: time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC),
-				ThirdRunRateSwitchDate:  time.Date(2028, 7, 1, 0, 0, 0, 0, time.UTC),
+				FirstRunRateSwitchDate:  civil.New(2026, 11, 1),
+				SecondRunRateSwitchDate: civil.New(2027, 5, 1),
+				ThirdRunRateSwitchDate:  civil.New(2028, 7, 1),
 			},
 			{
-				WarrantyExpDate:         time.Date(2025, 10, 31, 23, 59, 59, 0, time.UTC),
+				SerialNumber:            "1085719",
+				Position:                2,
+				WarrantyExpDate:         civil.New(2025, 10, 31),
 				WarrantyExpHours:        1000,
-				FirstRunRateSwitchDate:  time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
-				SecondRunRateSwitchDate: time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC),
-				ThirdRunRateSwitchDate:  time.Date(2028, 7, 1, 0, 0, 0, 0, time.UTC),
+				FirstRunRateSwitchDate:  civil.New(2026, 11, 1),
+				SecondRunRateSwitchDate: civil.New(2027, 5, 1),
+				ThirdRunRateSwitchDate:  civil.New(2028, 7, 1),
 			},
 		},
 	}
@@ -115,6 +119,8 @@ func BenchmarkCalculateWithVaryingEngineNumbers(b *testing.B) {
 			params.EngineParams = make([]EngineParams, numEngines)
 			for i := 0; i < numEngines; i++ {
 				params.EngineParams[i] = baseParams.EngineParams[0] // Use the same params for all engines
+				params.EngineParams[i].SerialNumber = fmt.Sprintf("ESN%03d", i+1)
+				params.EngineParams[i].Position = i + 1
 			}
 			b.ResetTimer()
 
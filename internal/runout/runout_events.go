@@ -0,0 +1,125 @@
+package runout
+
+import (
+	"financialapi/internal/civil"
+	"fmt"
+)
+
+// EngineEvent enrolls an engine in the contract or removes it from the
+// contract on Date. A removed engine is not billed from Date on. Swaps and
+// leases are a removal and a later enrollment.
+type EngineEvent struct {
+	Type string     `json:"type"`
+	Date civil.Date `json:"date"`
+}
+
+// Engine event types accepted in EngineEvent.Type.
+const (
+	EventEnroll = "enroll"
+	EventRemove = "remove"
+)
+
+// ActiveWindow is a run of days, both included, during which an engine is
+// enrolled.
+type ActiveWindow struct {
+	StartDate civil.Date
+	EndDate   civil.Date
+}
+
+// activeWindows returns the windows during which an engine is enrolled. An
+// engine whose first event is an enrollment joins the contract then; any
+// other engine is enrolled from the contract start.
+func activeWindows(params RunoutParams, engineParams EngineParams) []ActiveWindow {
+	windows := []ActiveWindow{}
+	active := len(engineParams.Events) == 0 || engineParams.Events[0].Type != EventEnroll
+	start := params.ContractStartDate
+	for _, event := range engineParams.Events {
+		if event.Type == EventEnroll {
+			start, active = event.Date, true
+			continue
+		}
+		if event.Date.After(start) {
+			windows = append(windows, ActiveWindow{StartDate: start, EndDate: event.Date.AddDays(-1)})
+		}
+		active = false
+	}
+	if active {
+		windows = append(windows, ActiveWindow{StartDate: start, EndDate: params.ContractEndDate})
+	}
+	return windows
+}
+
+// clipWindows returns the parts of windows from start to end, both included.
+func clipWindows(windows []ActiveWindow, start, end civil.Date) []ActiveWindow {
+	clipped := []ActiveWindow{}
+	for _, window := range windows {
+		window.StartDate = civil.Max(window.StartDate, start)
+		window.EndDate = civil.Min(window.EndDate, end)
+		if !window.EndDate.Before(window.StartDate) {
+			clipped = append(clipped, window)
+		}
+	}
+	return clipped
+}
+
+// activeShare returns the part of the period's runout, in the contract's
+// day-count convention, that the windows cover.
+func activeShare(params RunoutParams, period ContractPeriod, windows []ActiveWindow) float64 {
+	if len(windows) == 1 && windows[0] == (ActiveWindow{StartDate: period.RunoutStartDate, EndDate: period.RunoutEndDate}) {
+		return 1
+	}
+	if period.YearFraction == 0 {
+		return 0
+	}
+	years := 0.0
+	for _, window := range windows {
+		years += params.YearFraction(window.StartDate, window.EndDate)
+	}
+	return years / period.YearFraction
+}
+
+// allocateBuyIn charges each engine an equal share of the buy-in, pro-rated
+// to the part of the contract it is enrolled for in the contract's day-count
+// convention, in the first period that ends on or after it enrolls. A
+// terminated contract is measured against its original term to originalEnd.
+func allocateBuyIn(result *RunoutResult, params RunoutParams, originalEnd civil.Date) {
+	contractYears := params.YearFraction(params.ContractStartDate, originalEnd)
+	for e, summary := range result.Engines {
+		if len(summary.Active) == 0 {
+			continue
+		}
+		enrolledYears := 0.0
+		for _, window := range summary.Active {
+			enrolledYears += params.YearFraction(window.StartDate, window.EndDate)
+		}
+		share := enrolledYears / contractYears
+		for i := range result.Periods {
+			if !result.Periods[i].EndDate.Before(summary.Active[0].StartDate) {
+				result.Periods[i].Engines[e].BuyIn = params.BuyIn / float64(params.NumEngines) * share
+				break
+			}
+		}
+	}
+}
+
+func validateEngineEvents(events []EngineEvent, params RunoutParams, engine int) error {
+	for i, event := range events {
+		switch event.Type {
+		case EventEnroll, EventRemove:
+		default:
+			return fmt.Errorf("event %d of engine %d must be %q or %q", i+1, engine, EventEnroll, EventRemove)
+		}
+		if event.Date.Before(params.ContractStartDate) || event.Date.After(params.ContractEndDate) {
+			return fmt.Errorf("event %d of engine %d must fall within the contract", i+1, engine)
+		}
+		if i > 0 {
+			if !event.Date.After(events[i-1].Date) {
+				return fmt.Errorf("event %d of engine %d must be after the previous event", i+1, engine)
+			}
+			if event.Type == events[i-1].Type {
+				return fmt.Errorf("event %d of engine %d must alternate between %q and %q", i+1, engine, EventEnroll, EventRemove)
+			}
+		}
+	}
+	return nil
+}
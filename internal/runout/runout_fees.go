@@ -0,0 +1,141 @@
+package runout
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fee is one step of the fee waterfall. It takes Percent of its base, or a
+// fixed Amount per contract year, up to Cap per contract year when Cap is
+// set. Amount and Cap are pro-rated for a stub and for a period with a stub
+// merged in.
+//
+// The base is the period's gross revenue, the flight-hour and shortfall
+// revenue billed in it. FeeBaseGross (default) takes it less the earlier
+// fees named in NetOf, and FeeBaseNet takes it less every earlier fee.
+type Fee struct {
+	Name    string   `json:"name"`
+	Base    string   `json:"base"`
+	NetOf   []string `json:"netOf"`
+	Percent float64  `json:"percent"`
+	Amount  float64  `json:"amount"`
+	Cap     float64  `json:"cap"`
+}
+
+// Fee bases accepted in Fee.Base.
+const (
+	FeeBaseGross = "gross"
+	FeeBaseNet   = "net"
+)
+
+// Names of the fees of the default waterfall, which are also reported as
+// MgmtFeeRevenue, AICRevenue and TrustLoadRevenue.
+const (
+	FeeManagement = "management"
+	FeeAIC        = "aic"
+	FeeTrustLoad  = "trustLoad"
+)
+
+// FeeLine is the amount a fee takes in a period and the base it was taken
+// from.
+type FeeLine struct {
+	Name   string
+	Base   float64
+	Amount float64
+}
+
+// feeWaterfall returns FeeWaterfall, or by default the contract's fee
+// terms: the management fee on gross revenue, and the AIC and trust load
+// fees on revenue net of the management fee.
+func feeWaterfall(params RunoutParams) []Fee {
+	if len(params.FeeWaterfall) > 0 {
+		return params.FeeWaterfall
+	}
+	return []Fee{
+		{Name: FeeManagement, Percent: params.ManagementFees},
+		{Name: FeeAIC, NetOf: []string{FeeManagement}, Percent: params.AICFees},
+		{Name: FeeTrustLoad, NetOf: []string{FeeManagement}, Percent: params.TrustLoadFees},
+	}
+}
+
+// applyFeeWaterfall takes the fees in order from the period's gross revenue.
+func applyFeeWaterfall(period ContractPeriod, fees []Fee) []FeeLine {
+	gross := period.TotalFHRevenue + period.ShortfallRevenue
+	share := periodShare(period)
+
+	lines := make([]FeeLine, len(fees))
+	taken := make(map[string]float64, len(fees))
+	takenSoFar := 0.0
+	for i, fee := range fees {
+		base := gross
+		if fee.Base == FeeBaseNet {
+			base -= takenSoFar
+		}
+		for _, name := range fee.NetOf {
+			base -= taken[name]
+		}
+
+		amount := fee.Amount * share
+		if fee.Percent != 0 {
+			amount = math.Max(base, 0) * fee.Percent / 100
+		}
+		if fee.Cap > 0 {
+			amount = math.Min(amount, fee.Cap*share)
+		}
+
+		lines[i] = FeeLine{Name: fee.Name, Base: base, Amount: amount}
+		taken[fee.Name] = amount
+		takenSoFar += amount
+	}
+	return lines
+}
+
+// feeAmount returns the amount of the named fee among lines.
+func feeAmount(lines []FeeLine, name string) float64 {
+	for _, line := range lines {
+		if line.Name == name {
+			return line.Amount
+		}
+	}
+	return 0
+}
+
+func validateFeeWaterfall(fees []Fee) error {
+	earlier := make(map[string]bool)
+	for i, fee := range fees {
+		if fee.Name == "" {
+			return fmt.Errorf("fee %d needs a Name", i+1)
+		}
+		if earlier[fee.Name] {
+			return fmt.Errorf("fee %s appears more than once in the waterfall", fee.Name)
+		}
+		switch fee.Base {
+		case "", FeeBaseGross:
+		case FeeBaseNet:
+			if len(fee.NetOf) > 0 {
+				return fmt.Errorf("fee %s is already net of every earlier fee and cannot list NetOf", fee.Name)
+			}
+		default:
+			return fmt.Errorf("Base of fee %s must be %q or %q", fee.Name, FeeBaseGross, FeeBaseNet)
+		}
+		for _, name := range fee.NetOf {
+			if !earlier[name] {
+				return fmt.Errorf("fee %s can only be net of earlier fees, not %s", fee.Name, name)
+			}
+		}
+		if fee.Percent < 0 || fee.Percent > 100 {
+			return fmt.Errorf("Percent of fee %s must be between 0 and 100", fee.Name)
+		}
+		if fee.Amount < 0 {
+			return fmt.Errorf("Amount of fee %s cannot be negative", fee.Name)
+		}
+		if fee.Percent != 0 && fee.Amount != 0 {
+			return fmt.Errorf("fee %s takes either a Percent or an Amount, not both", fee.Name)
+		}
+		if fee.Cap < 0 {
+			return fmt.Errorf("Cap of fee %s cannot be negative", fee.Name)
+		}
+		earlier[fee.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package runout
+
+import (
+	"financialapi/internal/financials"
+	"fmt"
+)
+
+// Ensure GoalSeek implements ComputeEngine
+var _ financials.ComputeEngine = (*GoalSeek)(nil)
+
+// What runout goal seek solves for, in GoalSeekParams.SolveFor.
+const (
+	// SolveForRunRate sets FirstRunRate, SecondRunRate and ThirdRunRate to
+	// one rate.
+	SolveForRunRate = "runRate"
+	// SolveForRateMultiplier scales every rate of the contract and its
+	// engines by one factor.
+	SolveForRateMultiplier = "rateMultiplier"
+)
+
+// What runout goal seek drives to Target, in GoalSeekParams.SeekTarget.
+const (
+	SeekCumulativeTotalRevenue = "cumulativeTotalRevenue"
+	SeekTrustRevenue           = "trustRevenue"
+)
+
+// GoalSeekParams solves Params for the rate at which SeekTarget, over the
+// whole contract, reaches Target.
+type GoalSeekParams struct {
+	Params     RunoutParams            `json:"params"`
+	SolveFor   string                  `json:"solveFor"`
+	SeekTarget string                  `json:"seekTarget"`
+	Target     float64                 `json:"target"`
+	Solver     financials.SolverConfig `json:"solver"`
+}
+
+func (p GoalSeekParams) Validate() error {
+	if err := p.Params.Validate(); err != nil {
+		return err
+	}
+	if err := p.Solver.Validate(); err != nil {
+		return err
+	}
+	switch p.SolveFor {
+	case "", SolveForRunRate:
+		if len(p.Params.RateTiers) > 0 {
+			return fmt.Errorf("%s needs the run rate fields rather than RateTiers; solve for %s instead", SolveForRunRate, SolveForRateMultiplier)
+		}
+		// An engine with its own tiers or rates would not bill the solved
+		// run rate.
+		for i, ep := range p.Params.EngineParams {
+			if len(ep.RateTiers) > 0 || ep.hasRateOverrides() {
+				return fmt.Errorf("%s does not reach engine %d, which has its own rates; solve for %s instead", SolveForRunRate, i+1, SolveForRateMultiplier)
+			}
+		}
+	case SolveForRateMultiplier:
+	default:
+		return fmt.Errorf("SolveFor must be %q or %q", SolveForRunRate, SolveForRateMultiplier)
+	}
+	switch p.SeekTarget {
+	case "", SeekCumulativeTotalRevenue, SeekTrustRevenue:
+	default:
+		return fmt.Errorf("SeekTarget must be %q or %q", SeekCumulativeTotalRevenue, SeekTrustRevenue)
+	}
+	return nil
+}
+
+// GoalSeekResult holds the solved value, the contract rates it gives and the
+// runout at those rates. The rates each engine bills are in the tiers of
+// Result.Engines.
+type GoalSeekResult struct {
+	SolveFor      string
+	SeekTarget    string
+	SolvedValue   float64
+	Iterations    int
+	SolverMethod  string
+	WarrantyRate  float64
+	FirstRunRate  float64
+	SecondRunRate float64
+	ThirdRunRate  float64
+	RateTiers     []RateTier
+	Result        RunoutResult
+}
+
+type GoalSeek struct {
+	Params GoalSeekParams
+	result GoalSeekResult
+}
+
+func (gs *GoalSeek) Initialize(params interface{}) error {
+	if p, ok := params.(GoalSeekParams); ok {
+		gs.Params = p
+		return nil
+	}
+	return fmt.Errorf("invalid params type for runout GoalSeek")
+}
+
+func (gs *GoalSeek) Validate() error {
+	return gs.Params.Validate()
+}
+
+func (gs *GoalSeek) Compute() error {
+	result, err := SolveRates(gs.Params)
+	if err != nil {
+		return err
+	}
+	gs.result = result
+	return nil
+}
+
+func (gs *GoalSeek) GetResult() interface{} {
+	return gs.result
+}
+
+// NewGoalSeekCalculator creates a new runout GoalSeek instance
+func NewGoalSeekCalculator(params GoalSeekParams) financials.ComputeEngine {
+	gs := &GoalSeek{}
+	gs.Initialize(params)
+	return gs
+}
+
+// SolveRates finds the run rate or rate multiplier at which the runout
+// reaches its target, using the solver selected by params.Solver with
+// Calculate as the objective. The run rate starts from FirstRunRate and the
+// multiplier from 1.
+func SolveRates(params GoalSeekParams) (GoalSeekResult, error) {
+	if err := params.Validate(); err != nil {
+		return GoalSeekResult{}, err
+	}
+	solver, err := financials.NewSolver(params.Solver)
+	if err != nil {
+		return GoalSeekResult{}, err
+	}
+
+	solveFor := params.SolveFor
+	if solveFor == "" {
+		solveFor = SolveForRunRate
+	}
+	seekTarget := params.SeekTarget
+	if seekTarget == "" {
+		seekTarget = SeekCumulativeTotalRevenue
+	}
+
+	ratesAt := func(x float64) RunoutParams {
+		if solveFor == SolveForRateMultiplier {
+			return scaleRates(params.Params, x)
+		}
+		p := params.Params
+		p.FirstRunRate, p.SecondRunRate, p.ThirdRunRate = x, x, x
+		return p
+	}
+	objective := func(x float64) (float64, error) {
+		// Rates cannot be negative, so a target only a negative rate would
+		// reach has no solution.
+		if x < 0 {
+			return 0, fmt.Errorf("%w: the target needs %s = %g, below zero", financials.ErrNoSolution, solveFor, x)
+		}
+		result, err := Calculate(ratesAt(x))
+		if err != nil {
+			return 0, err
+		}
+		if seekTarget == SeekTrustRevenue {
+			return result.TrustRevenue - params.Target, nil
+		}
+		return result.CumulativeTotalRevenue - params.Target, nil
+	}
+
+	initialGuess := 1.0
+	if solveFor == SolveForRunRate {
+		initialGuess = params.Params.FirstRunRate
+	}
+	solved, iterations, err := solver.Solve(objective, initialGuess)
+	if err != nil {
+		return GoalSeekResult{}, err
+	}
+
+	p := ratesAt(solved)
+	result, err := Calculate(p)
+	if err != nil {
+		return GoalSeekResult{}, fmt.Errorf("%w: solved %s = %g is outside the valid range: %v", financials.ErrNoSolution, solveFor, solved, err)
+	}
+
+	return GoalSeekResult{
+		SolveFor:      solveFor,
+		SeekTarget:    seekTarget,
+		SolvedValue:   solved,
+		Iterations:    iterations,
+		SolverMethod:  params.Solver.MethodName(),
+		WarrantyRate:  p.WarrantyRate,
+		FirstRunRate:  p.FirstRunRate,
+		SecondRunRate: p.SecondRunRate,
+		ThirdRunRate:  p.ThirdRunRate,
+		RateTiers:     p.RateTiers,
+		Result:        result,
+	}, nil
+}
+
+// scaleRates returns a copy of params with every rate of the contract and
+// its engines multiplied by m.
+func scaleRates(params RunoutParams, m float64) RunoutParams {
+	scaleTiers := func(tiers []RateTier) []RateTier {
+		if tiers == nil {
+			return nil
+		}
+		scaled := append([]RateTier(nil), tiers...)
+		for i := range scaled {
+			scaled[i].Rate *= m
+		}
+		return scaled
+	}
+	scale := func(rate *float64) *float64 {
+		if rate == nil {
+			return nil
+		}
+		scaled := *rate * m
+		return &scaled
+	}
+
+	p := params
+	p.WarrantyRate *= m
+	p.FirstRunRate *= m
+	p.SecondRunRate *= m
+	p.ThirdRunRate *= m
+	p.RateTiers = scaleTiers(params.RateTiers)
+	p.EngineParams = append([]EngineParams(nil), params.EngineParams...)
+	for i := range p.EngineParams {
+		ep := &p.EngineParams[i]
+		ep.RateTiers = scaleTiers(ep.RateTiers)
+		ep.WarrantyRate = scale(ep.WarrantyRate)
+		ep.FirstRunRate = scale(ep.FirstRunRate)
+		ep.SecondRunRate = scale(ep.SecondRunRate)
+		ep.ThirdRunRate = scale(ep.ThirdRunRate)
+	}
+	return p
+}
@@ -0,0 +1,147 @@
+package runout
+
+import (
+	"financialapi/internal/civil"
+	"math"
+)
+
+// calculateMonthlySchedule splits the runout of every contract period into
+// billing months. Each month is a ContractPeriod of its own, tagged with the
+// ContractYearNumber of its period, with the tier days and flight-hour
+// revenue of the days it covers. Each engine's buy-in falls in the month it
+// enrolls and shortfall revenue in the last month of the year it is billed
+// in. The fee waterfall of a period is shared out in proportion to the gross
+// revenue it is charged on, so the months add up exactly to their period.
+func calculateMonthlySchedule(result *RunoutResult, params RunoutParams, actuals monthlyActuals) {
+	result.MonthlySchedule = []ContractPeriod{}
+	cumulativeRevenue := 0.0
+
+	for _, period := range result.Periods {
+		months := periodMonths(period, params)
+		before := make([]EngineData, len(params.EngineParams))
+		for m := range months {
+			month := &months[m]
+			month.Engines = make([]EngineData, len(params.EngineParams))
+			calculatePeriodDetails(month, params, result.Engines, period.ContractYearNumber, period.RateTrend)
+
+			// Days and hours are counted from the start of the period to the
+			// end of the month, less those up to the end of the month before,
+			// so that the months add up to their period in any day-count
+			// convention. Shortfall is measured over the contract year.
+			upTo := runoutUpTo(period, month.RunoutEndDate, params, result.Engines, actuals)
+			for e := range month.Engines {
+				subtractEngine(&month.Engines[e], upTo[e], before[e])
+			}
+			before = upTo
+			month.TotalFHRevenue = sumEngineFHRevenue(month.Engines)
+
+			// An engine's buy-in falls in the month it enrolls, or the first
+			// month when it enrolled before the runout.
+			for e, engine := range period.Engines {
+				if engine.BuyIn == 0 {
+					continue
+				}
+				enrolled := result.Engines[e].Active[0].StartDate
+				if !month.EndDate.Before(enrolled) && (m == 0 || months[m-1].EndDate.Before(enrolled)) {
+					month.Engines[e].BuyIn = engine.BuyIn
+					month.BuyIn += engine.BuyIn
+				}
+			}
+			if m == len(months)-1 {
+				for e := range month.Engines {
+					month.Engines[e].Shortfall = period.Engines[e].Shortfall
+					month.Engines[e].ShortfallRevenue = period.Engines[e].ShortfallRevenue
+				}
+				month.ShortfallRevenue = period.ShortfallRevenue
+			}
+
+			share := float64(month.NumOfRunoutDays) / float64(period.NumOfRunoutDays)
+			if gross := period.TotalFHRevenue + period.ShortfallRevenue; gross != 0 {
+				share = (month.TotalFHRevenue + month.ShortfallRevenue) / gross
+			}
+			month.Waterfall = make([]FeeLine, len(period.Waterfall))
+			for j, line := range period.Waterfall {
+				month.Waterfall[j] = FeeLine{Name: line.Name, Base: line.Base * share, Amount: line.Amount * share}
+			}
+			month.MgmtFeeRevenue = period.MgmtFeeRevenue * share
+			month.AICRevenue = period.AICRevenue * share
+			month.TrustLoadRevenue = period.TrustLoadRevenue * share
+
+			month.TrustRevenue = month.TotalFHRevenue + month.ShortfallRevenue - month.BuyIn
+			for _, line := range month.Waterfall {
+				month.TrustRevenue -= line.Amount
+			}
+			month.TotalRevenue = month.TotalFHRevenue + month.ShortfallRevenue
+
+			cumulativeRevenue += month.TotalRevenue
+			month.CumulativeTotalRevenue = cumulativeRevenue
+		}
+		result.MonthlySchedule = append(result.MonthlySchedule, months...)
+	}
+}
+
+// runoutUpTo returns the tier days, flight hours and flight-hour revenue of
+// every engine from the start of the period's runout to end.
+func runoutUpTo(period ContractPeriod, end civil.Date, params RunoutParams, summaries []EngineSummary, actuals monthlyActuals) []EngineData {
+	upTo := ContractPeriod{
+		RunoutStartDate: period.RunoutStartDate,
+		RunoutEndDate:   end,
+		NumOfRunoutDays: daysBetween(period.RunoutStartDate, end),
+		YearFraction:    params.YearFraction(period.RunoutStartDate, end),
+		Engines:         make([]EngineData, len(params.EngineParams)),
+	}
+	calculatePeriodDetails(&upTo, params, summaries, period.ContractYearNumber, period.RateTrend)
+	for e := range params.EngineParams {
+		calculateEngineRevenue(&upTo, params, actuals, e)
+	}
+	return upTo.Engines
+}
+
+// subtractEngine sets the tier days, flight hours and flight-hour revenue of
+// engine to those of upTo less those of before.
+func subtractEngine(engine *EngineData, upTo, before EngineData) {
+	engine.TotalDays = upTo.TotalDays - before.TotalDays
+	engine.Rates = upTo.Rates - before.Rates
+	engine.EscalatedRate = upTo.EscalatedRate - before.EscalatedRate
+	engine.FHUtilization = upTo.FHUtilization - before.FHUtilization
+	engine.FHRevenue = upTo.FHRevenue - before.FHRevenue
+	for i := range engine.Tiers {
+		tier, earlier := &engine.Tiers[i], TierData{}
+		if i < len(before.Tiers) {
+			earlier = before.Tiers[i]
+		}
+		tier.Days = upTo.Tiers[i].Days - earlier.Days
+		tier.Calc = upTo.Tiers[i].Calc - earlier.Calc
+		tier.FlightHours = upTo.Tiers[i].FlightHours - earlier.FlightHours
+		tier.FHRevenue = upTo.Tiers[i].FHRevenue - earlier.FHRevenue
+	}
+}
+
+// periodMonths splits the runout of a period into billing months of
+// NumOfDaysInMonth days from its start, rounded to whole days as they
+// accumulate, or at calendar month ends when NumOfDaysInMonth is 0. The
+// last month ends with the period.
+func periodMonths(period ContractPeriod, params RunoutParams) []ContractPeriod {
+	months := []ContractPeriod{}
+	start := period.RunoutStartDate
+	for !start.After(period.RunoutEndDate) {
+		end := civil.New(start.Year, start.Month+1, 1).AddDays(-1)
+		if params.NumOfDaysInMonth > 0 {
+			end = period.RunoutStartDate.AddDays(int(math.Round(float64(len(months)+1)*params.NumOfDaysInMonth)) - 1)
+		}
+		end = civil.Min(end, period.RunoutEndDate)
+		days := daysBetween(start, end)
+		months = append(months, ContractPeriod{
+			StartDate:          start,
+			EndDate:            end,
+			NumOfDays:          days,
+			RunoutStartDate:    start,
+			RunoutEndDate:      end,
+			NumOfRunoutDays:    days,
+			YearFraction:       params.YearFraction(start, end),
+			ContractYearNumber: period.ContractYearNumber,
+		})
+		start = end.AddDays(1)
+	}
+	return months
+}
@@ -1,21 +1,52 @@
 package runout
 
 import (
+	"financialapi/internal/civil"
+	"financialapi/internal/daycount"
 	"fmt"
-	"time"
 )
 
 type EngineParams struct {
-	WarrantyExpDate         time.Time `json:"warrantyExpDate"`
-	WarrantyExpHours        float64   `json:"warrantyExpHours"`
-	FirstRunRateSwitchDate  time.Time `json:"firstRunRateSwitchDate"`
-	SecondRunRateSwitchDate time.Time `json:"secondRunRateSwitchDate"`
-	ThirdRunRateSwitchDate  time.Time `json:"thirdRunRateSwitchDate"`
+	SerialNumber            string     `json:"serialNumber"`
+	Position                int        `json:"position"`
+	Model                   string     `json:"model"`
+	WarrantyExpDate         civil.Date `json:"warrantyExpDate"`
+	WarrantyExpHours        float64    `json:"warrantyExpHours"`
+	TSNAtContractStart      float64    `json:"tsnAtContractStart"`
+	FirstRunRateSwitchDate  civil.Date `json:"firstRunRateSwitchDate"`
+	SecondRunRateSwitchDate civil.Date `json:"secondRunRateSwitchDate"`
+	ThirdRunRateSwitchDate  civil.Date `json:"thirdRunRateSwitchDate"`
+
+	// Events optionally enroll the engine in the contract and remove it
+	// from the contract during the term, in date order. Days, revenue,
+	// minimum hours and buy-in are pro-rated to the windows it is enrolled.
+	Events []EngineEvent `json:"events"`
+
+	// RateTiers optionally gives this engine its own rate tiers, overriding
+	// RunoutParams.RateTiers and the warranty and run rate fields.
+	RateTiers []RateTier `json:"rateTiers"`
+
+	// AUHours, FlightHoursMinimum and the rate fields optionally override
+	// the contract's values for this engine. The rates apply only to an
+	// engine on the warranty and run rate fields, and are rejected for an
+	// engine that bills rate tiers.
+	AUHours            *float64 `json:"auHours"`
+	FlightHoursMinimum *float64 `json:"flightHoursMinimum"`
+	WarrantyRate       *float64 `json:"warrantyRate"`
+	FirstRunRate       *float64 `json:"firstRunRate"`
+	SecondRunRate      *float64 `json:"secondRunRate"`
+	ThirdRunRate       *float64 `json:"thirdRunRate"`
+}
+
+// hasRateOverrides reports whether the engine overrides any of the warranty
+// and run rate fields.
+func (ep EngineParams) hasRateOverrides() bool {
+	return ep.WarrantyRate != nil || ep.FirstRunRate != nil || ep.SecondRunRate != nil || ep.ThirdRunRate != nil
 }
 
 type RunoutParams struct {
-	ContractStartDate  time.Time      `json:"contractStartDate"`
-	ContractEndDate    time.Time      `json:"contractEndDate"`
+	ContractStartDate  civil.Date     `json:"contractStartDate"`
+	ContractEndDate    civil.Date     `json:"contractEndDate"`
 	AUHours            float64        `json:"auHours"`
 	WarrantyRate       float64        `json:"warrantyRate"`
 	FirstRunRate       float64        `json:"firstRunRate"`
@@ -32,6 +63,125 @@ type RunoutParams struct {
 	EnrollmentFees     float64        `json:"enrollmentFees"`
 	NumEngines         int            `json:"numEngines"`
 	EngineParams       []EngineParams `json:"engineParams"`
+
+	// RateEscalationByYear optionally overrides RateEscalation for
+	// individual years: entry i is the escalation in percent from contract
+	// year i+1 to year i+2. Years beyond its length use RateEscalation.
+	RateEscalationByYear []float64 `json:"rateEscalationByYear"`
+
+	// ShortfallBilling selects how hours below FlightHoursMinimum are
+	// billed: ShortfallNone (default) does not bill them, ShortfallAnnual
+	// bills each contract year's shortfall in that year, and
+	// ShortfallEndOfTerm carries excess hours forward against later
+	// shortfalls and bills what remains in the last contract year.
+	ShortfallBilling string `json:"shortfallBilling"`
+
+	// PeriodPolicy selects where contract years end: PeriodSnap (default)
+	// ends them at a month end near the start date's anniversary,
+	// PeriodAnniversary on the day before each anniversary, PeriodCalendar
+	// on 31 December and PeriodFiscal on the day before the first of
+	// FiscalYearStartMonth. StubHandling decides what happens to a first or
+	// last period shorter than 300 days: StubDrop (default) leaves it out,
+	// StubProrate keeps it as a period of its own and StubMerge adds it to
+	// the adjacent period.
+	PeriodPolicy         string `json:"periodPolicy"`
+	FiscalYearStartMonth int    `json:"fiscalYearStartMonth"`
+	StubHandling         string `json:"stubHandling"`
+
+	// IncludeMonthlySchedule adds the monthly breakdown of every contract
+	// period to RunoutResult.MonthlySchedule: billing months of
+	// NumOfDaysInMonth days, or calendar months when NumOfDaysInMonth is 0.
+	IncludeMonthlySchedule bool `json:"includeMonthlySchedule"`
+
+	// DayCountConvention selects how rate tier days are counted and turned
+	// into years for utilization and pro-rating: one of the conventions of
+	// package daycount. Empty counts actual days over NumOfDaysInYear.
+	DayCountConvention string `json:"dayCountConvention"`
+
+	// RateTiers optionally replaces the warranty and run rate fields with
+	// any number of tiers for every engine without tiers of its own.
+	RateTiers []RateTier `json:"rateTiers"`
+
+	// Actuals and ActualsCSV optionally give the hours each engine actually
+	// flew in past months, by serial number. Those months use the actual
+	// hours instead of AUHours, and RunoutResult.TrueUp compares them with
+	// the forecast. ActualsCSV is read by ParseActualsCSV.
+	Actuals    []ActualHours `json:"actuals"`
+	ActualsCSV string        `json:"actualsCsv"`
+
+	// FeeWaterfall optionally replaces ManagementFees, AICFees and
+	// TrustLoadFees with fees taken in order from each period's gross
+	// revenue.
+	FeeWaterfall []Fee `json:"feeWaterfall"`
+}
+
+// CountDays counts the days from start to end, both included, in the
+// contract's day-count convention.
+func (p RunoutParams) CountDays(start, end civil.Date) int {
+	if p.DayCountConvention == "" {
+		return daycount.Act365Fixed.Days(start, end) + 1
+	}
+	return daycount.Convention(p.DayCountConvention).Days(start, end.AddDays(1))
+}
+
+// YearFraction returns the length in years of the days from start to end,
+// both included, in the contract's day-count convention.
+func (p RunoutParams) YearFraction(start, end civil.Date) float64 {
+	if p.DayCountConvention == "" {
+		return float64(p.CountDays(start, end)) / p.NumOfDaysInYear
+	}
+	return daycount.Convention(p.DayCountConvention).YearFraction(start, end.AddDays(1))
+}
+
+// Shortfall billing modes accepted in RunoutParams.ShortfallBilling.
+const (
+	ShortfallNone      = "none"
+	ShortfallAnnual    = "annual"
+	ShortfallEndOfTerm = "end-of-term"
+)
+
+// Period policies accepted in RunoutParams.PeriodPolicy.
+const (
+	PeriodSnap        = "snap"
+	PeriodAnniversary = "anniversary"
+	PeriodCalendar    = "calendar"
+	PeriodFiscal      = "fiscal"
+)
+
+// Stub handling options accepted in RunoutParams.StubHandling.
+const (
+	StubDrop    = "drop"
+	StubProrate = "prorate"
+	StubMerge   = "merge"
+)
+
+// EscalationAfterYear returns the escalation in percent applied from the
+// given contract year to the next.
+func (p RunoutParams) EscalationAfterYear(year int) float64 {
+	if year >= 1 && year <= len(p.RateEscalationByYear) {
+		return p.RateEscalationByYear[year-1]
+	}
+	return p.RateEscalation
+}
+
+// EngineAUHours returns the annual utilization of an engine: its own
+// AUHours, or the contract's.
+func (p RunoutParams) EngineAUHours(ep EngineParams) float64 {
+	return valueOr(ep.AUHours, p.AUHours)
+}
+
+// EngineFlightHoursMinimum returns the annual minimum hours of an engine:
+// its own FlightHoursMinimum, or the contract's.
+func (p RunoutParams) EngineFlightHoursMinimum(ep EngineParams) float64 {
+	return valueOr(ep.FlightHoursMinimum, p.FlightHoursMinimum)
+}
+
+// valueOr returns the value of override when it is set, and value otherwise.
+func valueOr(override *float64, value float64) float64 {
+	if override != nil {
+		return *override
+	}
+	return value
 }
 
 func (p RunoutParams) Validate() error {
@@ -62,20 +212,52 @@ func (p RunoutParams) Validate() error {
 	if p.TrustLoadFees < 0 || p.TrustLoadFees > 100 {
 		return fmt.Errorf("TrustLoadFees must be between 0 and 100")
 	}
+	if err := validateFeeWaterfall(p.FeeWaterfall); err != nil {
+		return err
+	}
 	if p.BuyIn < 0 {
 		return fmt.Errorf("BuyIn cannot be negative")
 	}
 	if p.RateEscalation < 0 {
 		return fmt.Errorf("RateEscalation cannot be negative")
 	}
+	for i, escalation := range p.RateEscalationByYear {
+		if escalation < 0 {
+			return fmt.Errorf("RateEscalationByYear entry %d cannot be negative", i+1)
+		}
+	}
 	if p.FlightHoursMinimum < 0 {
 		return fmt.Errorf("FlightHoursMinimum cannot be negative")
 	}
+	switch p.ShortfallBilling {
+	case "", ShortfallNone, ShortfallAnnual, ShortfallEndOfTerm:
+	default:
+		return fmt.Errorf("ShortfallBilling must be %q, %q or %q", ShortfallNone, ShortfallAnnual, ShortfallEndOfTerm)
+	}
+	switch p.PeriodPolicy {
+	case "", PeriodSnap, PeriodAnniversary, PeriodCalendar:
+	case PeriodFiscal:
+		if p.FiscalYearStartMonth < 1 || p.FiscalYearStartMonth > 12 {
+			return fmt.Errorf("FiscalYearStartMonth must be between 1 and 12")
+		}
+	default:
+		return fmt.Errorf("PeriodPolicy must be %q, %q, %q or %q", PeriodSnap, PeriodAnniversary, PeriodCalendar, PeriodFiscal)
+	}
+	switch p.StubHandling {
+	case "", StubDrop, StubProrate, StubMerge:
+	default:
+		return fmt.Errorf("StubHandling must be %q, %q or %q", StubDrop, StubProrate, StubMerge)
+	}
+	if p.DayCountConvention != "" {
+		if err := daycount.Convention(p.DayCountConvention).Validate(); err != nil {
+			return err
+		}
+	}
 	if p.NumOfDaysInYear <= 0 {
 		return fmt.Errorf("NumOfDaysInYear must be positive")
 	}
-	if p.NumOfDaysInMonth <= 0 {
-		return fmt.Errorf("NumOfDaysInMonth must be positive")
+	if p.NumOfDaysInMonth != 0 && p.NumOfDaysInMonth < 1 {
+		return fmt.Errorf("NumOfDaysInMonth must be 0, for calendar months, or at least 1")
 	}
 	if p.EnrollmentFees < 0 {
 		return fmt.Errorf("EnrollmentFees cannot be negative")
@@ -87,7 +269,68 @@ func (p RunoutParams) Validate() error {
 		return fmt.Errorf("number of EngineParams must match NumEngines")
 	}
 
+	if err := validateRateTiers(p.RateTiers, p, ""); err != nil {
+		return err
+	}
+
+	actuals, err := p.AllActuals()
+	if err != nil {
+		return err
+	}
+	if err := validateActuals(actuals, p); err != nil {
+		return err
+	}
+
+	serialNumbers := make(map[string]bool)
 	for i, ep := range p.EngineParams {
+		if ep.SerialNumber != "" {
+			if serialNumbers[ep.SerialNumber] {
+				return fmt.Errorf("SerialNumber %s is used by more than one engine", ep.SerialNumber)
+			}
+			serialNumbers[ep.SerialNumber] = true
+		}
+		if ep.Position < 0 {
+			return fmt.Errorf("Position for engine %d cannot be negative", i+1)
+		}
+		if ep.TSNAtContractStart < 0 {
+			return fmt.Errorf("TSNAtContractStart for engine %d cannot be negative", i+1)
+		}
+		if err := validateEngineEvents(ep.Events, p, i+1); err != nil {
+			return err
+		}
+		if ep.AUHours != nil && *ep.AUHours <= 0 {
+			return fmt.Errorf("AUHours for engine %d must be positive", i+1)
+		}
+		if ep.FlightHoursMinimum != nil && *ep.FlightHoursMinimum < 0 {
+			return fmt.Errorf("FlightHoursMinimum for engine %d cannot be negative", i+1)
+		}
+		for _, rate := range []struct {
+			name  string
+			value *float64
+		}{
+			{"WarrantyRate", ep.WarrantyRate},
+			{"FirstRunRate", ep.FirstRunRate},
+			{"SecondRunRate", ep.SecondRunRate},
+			{"ThirdRunRate", ep.ThirdRunRate},
+		} {
+			if rate.value != nil && *rate.value < 0 {
+				return fmt.Errorf("%s for engine %d cannot be negative", rate.name, i+1)
+			}
+		}
+		if ep.hasRateOverrides() && (len(ep.RateTiers) > 0 || len(p.RateTiers) > 0) {
+			return fmt.Errorf("engine %d bills rate tiers, so its rates belong in the tiers rather than WarrantyRate or the run rate fields", i+1)
+		}
+		if len(ep.RateTiers) > 0 {
+			if err := validateRateTiers(ep.RateTiers, p, fmt.Sprintf("of engine %d ", i+1)); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(p.RateTiers) > 0 {
+			continue
+		}
+
+		// The engine uses the warranty and run rate fields.
 		if ep.WarrantyExpDate.Before(p.ContractStartDate) {
 			return fmt.Errorf("WarrantyExpDate for engine %d must be after ContractStartDate", i+1)
 		}
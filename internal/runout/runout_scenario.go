@@ -0,0 +1,343 @@
+package runout
+
+import (
+	"financialapi/internal/civil"
+	"financialapi/internal/financials"
+	"fmt"
+)
+
+// Ensure ScenarioCalculator implements ComputeEngine
+var _ financials.ComputeEngine = (*ScenarioCalculator)(nil)
+
+// Termination fee rules accepted in Termination.FeeType.
+const (
+	// TerminationFeeNone charges no fee.
+	TerminationFeeNone = "none"
+	// TerminationFeeFlat charges Amount.
+	TerminationFeeFlat = "flat"
+	// TerminationFeeRemainingValue charges Percent of the gross revenue the
+	// contract would have billed from the termination date to its end.
+	TerminationFeeRemainingValue = "remainingValue"
+	// TerminationFeeUnrecoveredBuyIn charges the part of the buy-in that
+	// the terminated contract no longer recovers, amortizing it over the
+	// original term in the contract's day-count convention.
+	TerminationFeeUnrecoveredBuyIn = "unrecoveredBuyIn"
+)
+
+// Termination ends the contract early: the operator exits on Date, which is
+// no longer billed, and pays a termination fee in the last period.
+type Termination struct {
+	Date    civil.Date `json:"date"`
+	FeeType string     `json:"feeType"`
+	Amount  float64    `json:"amount"`
+	Percent float64    `json:"percent"`
+}
+
+// Extension moves the contract end to EndDate. From the day after the
+// original end every engine bills Rate, when set, in the first extension
+// year, and rates escalate by RateEscalation, when set, from each extension
+// year to the next.
+type Extension struct {
+	EndDate        civil.Date `json:"endDate"`
+	Rate           *float64   `json:"rate"`
+	RateEscalation *float64   `json:"rateEscalation"`
+}
+
+// ScenarioParams prices Params with either a Termination or an Extension.
+type ScenarioParams struct {
+	Params      RunoutParams `json:"params"`
+	Termination *Termination `json:"termination"`
+	Extension   *Extension   `json:"extension"`
+}
+
+func (p ScenarioParams) Validate() error {
+	if err := p.Params.Validate(); err != nil {
+		return err
+	}
+	if (p.Termination == nil) == (p.Extension == nil) {
+		return fmt.Errorf("a scenario needs either a termination or an extension")
+	}
+
+	if t := p.Termination; t != nil {
+		// The termination must end the contract within the days it bills.
+		periods := calculateContractPeriods(p.Params, p.Params.ContractEndDate)
+		if len(periods) == 0 {
+			return fmt.Errorf("the contract bills no period to terminate")
+		}
+		first, last := periods[0].StartDate, periods[len(periods)-1].EndDate
+		if !t.Date.After(first) || t.Date.After(last) {
+			return fmt.Errorf("termination date must be after %s and no later than %s, the first and last days the contract bills", first, last)
+		}
+		for i, ep := range p.Params.EngineParams {
+			if len(ep.Events) > 0 && ep.Events[0].Type == EventEnroll && !ep.Events[0].Date.Before(t.Date) {
+				return fmt.Errorf("engine %d enrolls on or after the termination date", i+1)
+			}
+		}
+		switch t.FeeType {
+		case "", TerminationFeeNone, TerminationFeeRemainingValue, TerminationFeeUnrecoveredBuyIn:
+		case TerminationFeeFlat:
+			if t.Amount < 0 {
+				return fmt.Errorf("termination fee Amount cannot be negative")
+			}
+		default:
+			return fmt.Errorf("termination FeeType must be %q, %q, %q or %q", TerminationFeeNone, TerminationFeeFlat, TerminationFeeRemainingValue, TerminationFeeUnrecoveredBuyIn)
+		}
+		if t.Percent < 0 || t.Percent > 100 {
+			return fmt.Errorf("termination fee Percent must be between 0 and 100")
+		}
+	}
+
+	if e := p.Extension; e != nil {
+		if !e.EndDate.After(p.Params.ContractEndDate) {
+			return fmt.Errorf("extension EndDate must be after ContractEndDate")
+		}
+		if e.Rate != nil && *e.Rate < 0 {
+			return fmt.Errorf("extension Rate cannot be negative")
+		}
+		if e.RateEscalation != nil && *e.RateEscalation < 0 {
+			return fmt.Errorf("extension RateEscalation cannot be negative")
+		}
+	}
+	return nil
+}
+
+// PeriodDelta is the change in a contract year's revenue lines from the base
+// to the modified contract. A year missing from either counts as zero.
+type PeriodDelta struct {
+	ContractYearNumber     int
+	TotalFHRevenue         float64
+	ShortfallRevenue       float64
+	TrustRevenue           float64
+	TotalRevenue           float64
+	CumulativeTotalRevenue float64
+}
+
+// ScenarioResult sets the modified contract beside the base contract.
+// Modified includes any termination fee in its last period.
+type ScenarioResult struct {
+	Base              RunoutResult
+	Modified          RunoutResult
+	TerminationFee    float64
+	Deltas            []PeriodDelta
+	TrustRevenueDelta float64
+	TotalRevenueDelta float64
+}
+
+type ScenarioCalculator struct {
+	Params ScenarioParams
+	result ScenarioResult
+}
+
+func (s *ScenarioCalculator) Initialize(params interface{}) error {
+	if p, ok := params.(ScenarioParams); ok {
+		s.Params = p
+		return nil
+	}
+	return fmt.Errorf("invalid params type for runout Scenario")
+}
+
+func (s *ScenarioCalculator) Validate() error {
+	return s.Params.Validate()
+}
+
+func (s *ScenarioCalculator) Compute() error {
+	result, err := CalculateScenario(s.Params)
+	if err != nil {
+		return err
+	}
+	s.result = result
+	return nil
+}
+
+func (s *ScenarioCalculator) GetResult() interface{} {
+	return s.result
+}
+
+// NewScenarioCalculator creates a new ScenarioCalculator instance
+func NewScenarioCalculator(params ScenarioParams) financials.ComputeEngine {
+	s := &ScenarioCalculator{}
+	s.Initialize(params)
+	return s
+}
+
+// CalculateScenario calculates the base and the modified contract and the
+// change in every contract year.
+func CalculateScenario(params ScenarioParams) (ScenarioResult, error) {
+	if err := params.Validate(); err != nil {
+		return ScenarioResult{}, err
+	}
+
+	base, err := Calculate(params.Params)
+	if err != nil {
+		return ScenarioResult{}, err
+	}
+
+	// A terminated contract keeps the contract years and the buy-in of its
+	// original term; an extended one is agreed to its new end.
+	var modifiedParams RunoutParams
+	originalEnd := params.Params.ContractEndDate
+	if params.Termination != nil {
+		modifiedParams, err = terminatedParams(params.Params, params.Termination.Date)
+	} else {
+		modifiedParams, err = extendedParams(params.Params, *params.Extension)
+		originalEnd = modifiedParams.ContractEndDate
+	}
+	if err != nil {
+		return ScenarioResult{}, err
+	}
+	modified, err := calculate(modifiedParams, originalEnd)
+	if err != nil {
+		return ScenarioResult{}, fmt.Errorf("invalid modified contract: %v", err)
+	}
+
+	result := ScenarioResult{Base: base, Modified: modified}
+	if params.Termination != nil {
+		result.TerminationFee = terminationFee(*params.Termination, base, modified)
+		addTerminationFee(&result.Modified, result.TerminationFee)
+	}
+
+	numYears := max(len(base.Periods), len(result.Modified.Periods))
+	result.Deltas = make([]PeriodDelta, numYears)
+	for i := range result.Deltas {
+		var b, m ContractPeriod
+		if i < len(base.Periods) {
+			b = base.Periods[i]
+		}
+		if i < len(result.Modified.Periods) {
+			m = result.Modified.Periods[i]
+		}
+		result.Deltas[i] = PeriodDelta{
+			ContractYearNumber:     i + 1,
+			TotalFHRevenue:         m.TotalFHRevenue - b.TotalFHRevenue,
+			ShortfallRevenue:       m.ShortfallRevenue - b.ShortfallRevenue,
+			TrustRevenue:           m.TrustRevenue - b.TrustRevenue,
+			TotalRevenue:           m.TotalRevenue - b.TotalRevenue,
+			CumulativeTotalRevenue: m.CumulativeTotalRevenue - b.CumulativeTotalRevenue,
+		}
+	}
+	result.TrustRevenueDelta = result.Modified.TrustRevenue - base.TrustRevenue
+	result.TotalRevenueDelta = result.Modified.TotalRevenue - base.TotalRevenue
+
+	return result, nil
+}
+
+// terminatedParams ends the contract the day before date, leaving out the
+// engine events and actuals that fall after the new end. It is calculated
+// against the original end, so the part year it leaves is billed whenever
+// the original contract bills that year.
+func terminatedParams(params RunoutParams, date civil.Date) (RunoutParams, error) {
+	p := params
+	p.ContractEndDate = date.AddDays(-1)
+
+	p.EngineParams = append([]EngineParams(nil), params.EngineParams...)
+	for i := range p.EngineParams {
+		events := []EngineEvent{}
+		for _, event := range p.EngineParams[i].Events {
+			if !event.Date.After(p.ContractEndDate) {
+				events = append(events, event)
+			}
+		}
+		p.EngineParams[i].Events = events
+	}
+
+	actuals, err := params.AllActuals()
+	if err != nil {
+		return RunoutParams{}, err
+	}
+	p.Actuals, p.ActualsCSV = []ActualHours{}, ""
+	for _, actual := range actuals {
+		if !actual.Month.After(p.ContractEndDate) {
+			p.Actuals = append(p.Actuals, actual)
+		}
+	}
+	return p, nil
+}
+
+// extendedParams moves the contract end to the extension's EndDate. The
+// extension rate becomes a last rate tier of every engine, scaled so that
+// the rate trend of the first extension year brings it to the agreed rate.
+// Tiers that would have started after the original end start with the
+// extension instead, which then replaces them.
+func extendedParams(params RunoutParams, extension Extension) (RunoutParams, error) {
+	p := params
+	p.ContractEndDate = extension.EndDate
+	start := params.ContractEndDate.AddDays(1)
+
+	periods := calculateContractPeriods(p, p.ContractEndDate)
+	first := len(periods) - 1
+	for i, period := range periods {
+		if !period.EndDate.Before(start) {
+			first = i
+			break
+		}
+	}
+
+	if extension.RateEscalation != nil && len(periods) > 1 {
+		p.RateEscalationByYear = make([]float64, len(periods)-1)
+		for year := 1; year < len(periods); year++ {
+			p.RateEscalationByYear[year-1] = params.EscalationAfterYear(year)
+			if year > first {
+				p.RateEscalationByYear[year-1] = *extension.RateEscalation
+			}
+		}
+	}
+
+	if extension.Rate != nil && first >= 0 {
+		trend := rateTrends(p, len(periods))[first]
+		p.RateTiers = nil
+		p.EngineParams = append([]EngineParams(nil), params.EngineParams...)
+		for i := range p.EngineParams {
+			ep := &p.EngineParams[i]
+			tiers := append([]RateTier(nil), engineRateTiers(params, *ep)...)
+			for j := 1; j < len(tiers); j++ {
+				if tiers[j].EffectiveDate.After(start) {
+					tiers[j].EffectiveDate = start
+				}
+			}
+			ep.RateTiers = append(tiers, RateTier{Name: "extension", EffectiveDate: start, Rate: *extension.Rate / trend})
+			// The engine's own rates are now part of its tiers.
+			ep.WarrantyRate, ep.FirstRunRate, ep.SecondRunRate, ep.ThirdRunRate = nil, nil, nil, nil
+		}
+	}
+	return p, nil
+}
+
+// terminationFee prices the fee of the termination rule against the base
+// and the terminated contract.
+func terminationFee(termination Termination, base, modified RunoutResult) float64 {
+	switch termination.FeeType {
+	case TerminationFeeFlat:
+		return termination.Amount
+	case TerminationFeeRemainingValue:
+		remaining := base.TotalFHRevenue + base.ShortfallRevenue - modified.TotalFHRevenue - modified.ShortfallRevenue
+		return remaining * termination.Percent / 100
+	case TerminationFeeUnrecoveredBuyIn:
+		return base.BuyIn - modified.BuyIn
+	default:
+		return 0
+	}
+}
+
+// addTerminationFee adds the fee to the trust and total revenue of the last
+// period, and of its last month when there is a monthly schedule.
+func addTerminationFee(result *RunoutResult, fee float64) {
+	if fee == 0 || len(result.Periods) == 0 {
+		return
+	}
+	last := &result.Periods[len(result.Periods)-1]
+	last.TerminationFee = fee
+	last.TrustRevenue += fee
+	last.TotalRevenue += fee
+	last.CumulativeTotalRevenue += fee
+	if n := len(result.MonthlySchedule); n > 0 {
+		month := &result.MonthlySchedule[n-1]
+		month.TerminationFee = fee
+		month.TrustRevenue += fee
+		month.TotalRevenue += fee
+		month.CumulativeTotalRevenue += fee
+	}
+	result.TerminationFee = fee
+	result.TrustRevenue += fee
+	result.TotalRevenue += fee
+	result.CumulativeTotalRevenue += fee
+}
@@ -3,7 +3,14 @@
 package runout
 
 import (
+	"encoding/json"
+	"errors"
+	"financialapi/internal/civil"
+	"financialapi/internal/daycount"
+	"financialapi/internal/financials"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,8 +22,8 @@ func almostEqual(a, b, tolerance float64) bool {
 
 func TestRunoutEngine(t *testing.T) {
 	params := RunoutParams{
-		ContractStartDate:  time.Date(2022, 1, 14, 0, 0, 0, 0, time.UTC),
-		ContractEndDate:    time.Date(2034, 2, 14, 23, 59, 59, 0, time.UTC),
+		ContractStartDate:  civil.New(2022, 1, 14),
+		ContractEndDate:    civil.New(2034, 2, 14),
 		AUHours:            480,
 		WarrantyRate:       243.6,
 		FirstRunRate:       255.13,
@@ -34,18 +41,22 @@ func TestRunoutEngine(t *testing.T) {
 		NumEngines:         2,
 		EngineParams: []EngineParams{
 			{
-				WarrantyExpDate:         time.Date(2025, 10, 31, 23, 59, 59, 0, time.UTC),
+				SerialNumber:            "1085718",
+				Position:                1,
+				WarrantyExpDate:         civil.New(2025, 10, 31),
 				WarrantyExpHours:        1000,
-				FirstRunRateSwitchDate:  time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
-				SecondRunRateSwitchDate: time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC),
-				ThirdRunRateSwitchDate:  time.Date(2028, 7, 1, 0, 0, 0, 0, time.UTC),
+				FirstRunRateSwitchDate:  civil.New(2026, 11, 1),
+				SecondRunRateSwitchDate: civil.New(2027, 5, 1),
+				ThirdRunRateSwitchDate:  civil.New(2028, 7, 1),
 			},
 			{
-				WarrantyExpDate:         time.Date(2025, 10, 31, 23, 59, 59, 0, time.UTC),
+				SerialNumber:            "1085719",
+				Position:                2,
+				WarrantyExpDate:         civil.New(2025, 10, 31),
 				WarrantyExpHours:        1000,
-				FirstRunRateSwitchDate:  time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
-				SecondRunRateSwitchDate: time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC),
-				ThirdRunRateSwitchDate:  time.Date(2028, 7, 1, 0, 0, 0, 0, time.UTC),
+				FirstRunRateSwitchDate:  civil.New(2026, 11, 1),
+				SecondRunRateSwitchDate: civil.New(2027, 5, 1),
+				ThirdRunRateSwitchDate:  civil.New(2028, 7, 1),
 			},
 		},
 	}
@@ -84,7 +95,7 @@ func TestRunoutEngine(t *testing.T) {
 
 	// Check the first period
 	firstPeriod := runoutResult.Periods[0]
-	if !firstPeriod.StartDate.Equal(params.ContractStartDate) {
+	if firstPeriod.StartDate != params.ContractStartDate {
 		t.Errorf("First period start date incorrect. Expected %v, got %v", params.ContractStartDate, firstPeriod.StartDate)
 	}
 	if firstPeriod.NumOfDays != 352 {
@@ -99,8 +110,8 @@ func TestRunoutEngine(t *testing.T) {
 
 	// Check engine data for the first period
 	engine1 := firstPeriod.Engines[0]
-	if engine1.WarrantyRateDays != 352 {
-		t.Errorf("First period, engine 1 warranty rate days incorrect. Expected 352, got %d", engine1.WarrantyRateDays)
+	if engine1.Tiers[0].Days != 352 {
+		t.Errorf("First period, engine 1 warranty rate days incorrect. Expected 352, got %d", engine1.Tiers[0].Days)
 	}
 	if !almostEqual(engine1.FHRevenue, 112763.4410958904, 0.01) {
 		t.Errorf("First period, engine 1 FH revenue incorrect. Expected 112763.4410958904, got %f", engine1.FHRevenue)
@@ -108,8 +119,8 @@ func TestRunoutEngine(t *testing.T) {
 
 	// Check the last period
 	lastPeriod := runoutResult.Periods[len(runoutResult.Periods)-1]
-	if !lastPeriod.EndDate.Equal(time.Date(2033, 12, 31, 23, 59, 59, 0, time.UTC)) {
-		t.Errorf("Last period end date incorrect. Expected %v, got %v", time.Date(2033, 12, 31, 23, 59, 59, 0, time.UTC), lastPeriod.EndDate)
+	if lastPeriod.EndDate != civil.New(2033, 12, 31) {
+		t.Errorf("Last period end date incorrect. Expected %v, got %v", civil.New(2033, 12, 31), lastPeriod.EndDate)
 	}
 	if !almostEqual(lastPeriod.RateTrend, 2.51606537898244, 0.0001) {
 		t.Errorf("Last period rate trend incorrect. Expected 2.51606537898244, got %f", lastPeriod.RateTrend)
@@ -118,24 +129,47 @@ func TestRunoutEngine(t *testing.T) {
 		t.Errorf("Last period total FH revenue incorrect. Expected 616246.8097341983, got %f", lastPeriod.TotalFHRevenue)
 	}
 
+	// A numeric serial number is still reported as the EngineID.
+	for i, engine := range firstPeriod.Engines {
+		if expected := []int{1085718, 1085719}[i]; engine.EngineID != expected {
+			t.Errorf("Engine %d: expected EngineID %d, got %d", i+1, expected, engine.EngineID)
+		}
+	}
+
+	// Every day of a period is billed at exactly one rate.
+	for _, period := range runoutResult.Periods {
+		for _, engine := range period.Engines {
+			if engine.TotalDays != period.NumOfRunoutDays {
+				t.Errorf("Year %d engine %s has %d rate days in a %d day period", period.ContractYearNumber, engine.SerialNumber, engine.TotalDays, period.NumOfRunoutDays)
+			}
+		}
+	}
+
+	// Both engines reach their 1000 warranty hours on 2024-02-13, before WarrantyExpDate.
+	for _, summary := range runoutResult.Engines {
+		if summary.Tiers[1].Trigger != TriggerHours {
+			t.Errorf("Engine %s warranty trigger incorrect. Expected %s, got %s", summary.SerialNumber, TriggerHours, summary.Tiers[1].Trigger)
+		}
+	}
+
 	// Check overall totals
-	if !almostEqual(runoutResult.TotalFHRevenue, 4805005.2476703655, 0.01) {
-		t.Errorf("Total FH revenue incorrect. Expected 4805005.2476703655, got %f", runoutResult.TotalFHRevenue)
+	if !almostEqual(runoutResult.TotalFHRevenue, 4830369.7183293561, 0.01) {
+		t.Errorf("Total FH revenue incorrect. Expected 4830369.7183293561, got %f", runoutResult.TotalFHRevenue)
 	}
-	if !almostEqual(runoutResult.MgmtFeeRevenue, 720750.7871505547, 0.01) {
-		t.Errorf("Management fee revenue incorrect. Expected 720750.7871505547, got %f", runoutResult.MgmtFeeRevenue)
+	if !almostEqual(runoutResult.MgmtFeeRevenue, 724555.4577494033, 0.01) {
+		t.Errorf("Management fee revenue incorrect. Expected 724555.4577494033, got %f", runoutResult.MgmtFeeRevenue)
 	}
-	if !almostEqual(runoutResult.AICRevenue, 4805003.2076703645, 0.01) {
-		t.Errorf("AIC revenue incorrect. Expected 4805003.2076703645, got %f", runoutResult.AICRevenue)
+	if !almostEqual(runoutResult.AICRevenue, 821162.8521159906, 0.01) {
+		t.Errorf("AIC revenue incorrect. Expected 821162.8521159906, got %f", runoutResult.AICRevenue)
 	}
-	if !almostEqual(runoutResult.TrustLoadRevenue, 4805004.943710365, 0.01) {
-		t.Errorf("Trust load revenue incorrect. Expected 4805004.943710365, got %f", runoutResult.TrustLoadRevenue)
+	if !almostEqual(runoutResult.TrustLoadRevenue, 122353.2649652826, 0.01) {
+		t.Errorf("Trust load revenue incorrect. Expected 122353.2649652826, got %f", runoutResult.TrustLoadRevenue)
 	}
-	if !almostEqual(runoutResult.TrustRevenue, -6878044.74086092, 0.01) {
-		t.Errorf("Trust revenue incorrect. Expected -6878044.74086092, got %f", runoutResult.TrustRevenue)
+	if !almostEqual(runoutResult.TrustRevenue, 1810007.0934986801, 0.01) {
+		t.Errorf("Trust revenue incorrect. Expected 1810007.0934986801, got %f", runoutResult.TrustRevenue)
 	}
-	if !almostEqual(runoutResult.TotalRevenue, 4805005.2476703655, 0.01) {
-		t.Errorf("Total revenue incorrect. Expected 4805005.2476703655, got %f", runoutResult.TotalRevenue)
+	if !almostEqual(runoutResult.TotalRevenue, 4830369.7183293561, 0.01) {
+		t.Errorf("Total revenue incorrect. Expected 4830369.7183293561, got %f", runoutResult.TotalRevenue)
 	}
 
 	// Check Buy-In and Enrollment Fees
@@ -147,7 +181,1379 @@ func TestRunoutEngine(t *testing.T) {
 	}
 
 	// Check CumulativeTotalRevenue
-	if !almostEqual(runoutResult.CumulativeTotalRevenue, 4805005.2476703655, 0.01) {
-		t.Errorf("Cumulative Total Revenue incorrect. Expected 4805005.2476703655, got %f", runoutResult.CumulativeTotalRevenue)
+	if !almostEqual(runoutResult.CumulativeTotalRevenue, 4830369.7183293561, 0.01) {
+		t.Errorf("Cumulative Total Revenue incorrect. Expected 4830369.7183293561, got %f", runoutResult.CumulativeTotalRevenue)
+	}
+}
+
+func TestRunoutFleetSize(t *testing.T) {
+	base := getTestParams()
+
+	for _, numEngines := range []int{1, 2, 3} {
+		params := base
+		params.NumEngines = numEngines
+		params.EngineParams = make([]EngineParams, numEngines)
+		for i := range params.EngineParams {
+			params.EngineParams[i] = base.EngineParams[0]
+			params.EngineParams[i].SerialNumber = fmt.Sprintf("ESN%03d", i+1)
+			params.EngineParams[i].Position = i + 1
+			params.EngineParams[i].Model = "PW127M"
+		}
+
+		result, err := Calculate(params)
+		if err != nil {
+			t.Fatalf("Calculate with %d engines returned an error: %v", numEngines, err)
+		}
+
+		for _, period := range result.Periods {
+			if len(period.Engines) != numEngines {
+				t.Fatalf("Expected %d engines in period %d, got %d", numEngines, period.ContractYearNumber, len(period.Engines))
+			}
+			for i, engine := range period.Engines {
+				if engine.SerialNumber != params.EngineParams[i].SerialNumber || engine.Position != i+1 || engine.Model != "PW127M" {
+					t.Errorf("Engine %d identity incorrect, got %s at position %d (%s)", i+1, engine.SerialNumber, engine.Position, engine.Model)
+				}
+			}
+		}
+
+		// Identical engines contribute identical revenue.
+		single := result.Periods[0].Engines[0].FHRevenue
+		if !almostEqual(result.Periods[0].TotalFHRevenue, single*float64(numEngines), 0.01) {
+			t.Errorf("Expected first period FH revenue %f for %d engines, got %f", single*float64(numEngines), numEngines, result.Periods[0].TotalFHRevenue)
+		}
+	}
+}
+
+func TestRunoutDuplicateSerialNumber(t *testing.T) {
+	params := getTestParams()
+	params.EngineParams[1].SerialNumber = params.EngineParams[0].SerialNumber
+
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a duplicate serial number")
+	}
+}
+
+func TestRunoutRateTrend(t *testing.T) {
+	params := getTestParams()
+	params.RateEscalation = 5
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	for i, period := range result.Periods {
+		expected := math.Pow(1.05, float64(i))
+		if !almostEqual(period.RateTrend, expected, 1e-12) {
+			t.Errorf("Period %d rate trend incorrect. Expected %f, got %f", i+1, expected, period.RateTrend)
+		}
+	}
+
+	// Overrides apply to the listed years; later years fall back to RateEscalation.
+	params.RateEscalationByYear = []float64{10, 0, 3}
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	expected := []float64{1, 1.1, 1.1, 1.1 * 1.03, 1.1 * 1.03 * 1.05}
+	for i, trend := range expected {
+		if !almostEqual(result.Periods[i].RateTrend, trend, 1e-12) {
+			t.Errorf("Period %d rate trend incorrect. Expected %f, got %f", i+1, trend, result.Periods[i].RateTrend)
+		}
+	}
+
+	params.RateEscalationByYear = []float64{-1}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a negative RateEscalationByYear entry")
+	}
+}
+
+func TestRunoutLongContract(t *testing.T) {
+	params := getTestParams()
+	params.ContractEndDate = civil.New(2062, 12, 31)
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	if len(result.Periods) != 40 {
+		t.Fatalf("Expected 40 periods, got %d", len(result.Periods))
+	}
+
+	last := result.Periods[len(result.Periods)-1]
+	if !almostEqual(last.RateTrend, math.Pow(1.0875, 39), 1e-9) {
+		t.Errorf("Last period rate trend incorrect. Expected %f, got %f", math.Pow(1.0875, 39), last.RateTrend)
+	}
+	if last.TotalFHRevenue <= 0 {
+		t.Errorf("Expected revenue in the last period, got %f", last.TotalFHRevenue)
+	}
+}
+
+func TestRunoutWarrantyExpiry(t *testing.T) {
+	params := getTestParams()
+	params.EngineParams[0].WarrantyExpHours = 5000
+	params.EngineParams[0].TSNAtContractStart = 4000
+	params.EngineParams[1].WarrantyExpHours = 0
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// 1000 hours remain at 480/365 hours a day, reached on day 761 of the contract.
+	hoursEnd := civil.New(2025, 1, 30)
+	first := result.Engines[0]
+	if first.Tiers[1].Trigger != TriggerHours || first.Tiers[1].StartDate.AddDays(-1) != hoursEnd {
+		t.Errorf("Engine 1 warranty end incorrect. Expected %v (hours), got %v (%s)", hoursEnd, first.Tiers[1].StartDate.AddDays(-1), first.Tiers[1].Trigger)
+	}
+	second := result.Engines[1]
+	if second.Tiers[1].Trigger != TriggerDate || second.Tiers[1].StartDate.AddDays(-1) != params.EngineParams[1].WarrantyExpDate {
+		t.Errorf("Engine 2 warranty end incorrect. Expected %v (date), got %v (%s)", params.EngineParams[1].WarrantyExpDate, second.Tiers[1].StartDate.AddDays(-1), second.Tiers[1].Trigger)
+	}
+
+	// The rate switches the day after the hours limit is reached.
+	year3 := result.Periods[2].Engines[0]
+	if year3.Tiers[0].Days != 30 || year3.Tiers[1].Days != 335 {
+		t.Errorf("Year 3 engine 1 days incorrect. Expected 30 warranty and 335 first run, got %d and %d", year3.Tiers[0].Days, year3.Tiers[1].Days)
+	}
+
+	// An engine already past its warranty hours starts on the first run rate.
+	params.EngineParams[0].TSNAtContractStart = 6000
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	year1 := result.Periods[0].Engines[0]
+	if year1.Tiers[0].Days != 0 || year1.Tiers[1].Days != year1.TotalDays {
+		t.Errorf("Expected no warranty days in year 1, got %d warranty and %d first run", year1.Tiers[0].Days, year1.Tiers[1].Days)
+	}
+}
+
+func TestRunoutShortfallBilling(t *testing.T) {
+	params := getTestParams()
+	params.ContractEndDate = civil.New(2027, 11, 30)
+	params.FlightHoursMinimum = 475
+
+	// Without billing the shortfall is reported but earns nothing.
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	last := result.Periods[len(result.Periods)-1]
+	if len(result.Periods) != 5 || last.NumOfDays != 334 {
+		t.Fatalf("Expected 5 periods ending with a 334 day year, got %d ending with %d days", len(result.Periods), last.NumOfDays)
+	}
+	if last.Engines[0].Shortfall <= 0 || result.ShortfallRevenue != 0 {
+		t.Errorf("Expected an unbilled shortfall, got %f hours and %f revenue", last.Engines[0].Shortfall, result.ShortfallRevenue)
+	}
+	unbilled := result.TotalRevenue
+
+	// Annual billing charges the short final year at its escalated hourly rate.
+	params.ShortfallBilling = ShortfallAnnual
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	last = result.Periods[len(result.Periods)-1]
+	engine := last.Engines[0]
+	expected := engine.Shortfall * engine.EscalatedRate / float64(engine.TotalDays)
+	if !almostEqual(engine.ShortfallRevenue, expected, 1e-6) {
+		t.Errorf("Annual shortfall revenue incorrect. Expected %f, got %f", expected, engine.ShortfallRevenue)
+	}
+	if !almostEqual(result.ShortfallRevenue, 2*expected, 1e-6) || !almostEqual(last.ShortfallRevenue, 2*expected, 1e-6) {
+		t.Errorf("Expected total shortfall revenue %f, got %f", 2*expected, result.ShortfallRevenue)
+	}
+	if !almostEqual(result.TotalRevenue, unbilled+2*expected, 1e-6) {
+		t.Errorf("Expected total revenue %f to include the shortfall, got %f", unbilled+2*expected, result.TotalRevenue)
+	}
+
+	// End-of-term billing first uses the excess hours of the full years.
+	params.ShortfallBilling = ShortfallEndOfTerm
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	excess := 0.0
+	for _, period := range result.Periods[:len(result.Periods)-1] {
+		excess += period.Engines[0].FHUtilization - params.FlightHoursMinimum
+		if period.ShortfallRevenue != 0 {
+			t.Errorf("Expected no shortfall revenue before the last year, got %f in year %d", period.ShortfallRevenue, period.ContractYearNumber)
+		}
+	}
+	expected = (engine.Shortfall - excess) * engine.EscalatedRate / float64(engine.TotalDays)
+	if !almostEqual(result.Periods[len(result.Periods)-1].Engines[0].ShortfallRevenue, expected, 1e-6) {
+		t.Errorf("End-of-term shortfall revenue incorrect. Expected %f, got %f", expected, result.Periods[len(result.Periods)-1].Engines[0].ShortfallRevenue)
+	}
+
+	// Excess hours that cover the whole shortfall leave nothing to bill.
+	params.FlightHoursMinimum = 470
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	if result.ShortfallRevenue != 0 {
+		t.Errorf("Expected no end-of-term shortfall revenue, got %f", result.ShortfallRevenue)
+	}
+
+	params.ShortfallBilling = "monthly"
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown ShortfallBilling mode")
+	}
+}
+
+func TestRunoutPeriodPolicy(t *testing.T) {
+	base := getTestParams()
+	base.ContractStartDate = civil.New(2022, 1, 14)
+	base.ContractEndDate = civil.New(2034, 2, 14)
+	contractDays := daysBetween(base.ContractStartDate, base.ContractEndDate)
+
+	tests := []struct {
+		policy, stubs       string
+		fiscalMonth         int
+		periods             int
+		firstDays, lastDays int
+		coversContract      bool
+	}{
+		{"", "", 0, 12, 352, 365, false},
+		{PeriodSnap, StubProrate, 0, 13, 352, 45, true},
+		{PeriodSnap, StubMerge, 0, 12, 352, 410, true},
+		{PeriodAnniversary, StubDrop, 0, 12, 365, 365, false},
+		{PeriodAnniversary, StubProrate, 0, 13, 365, 32, true},
+		{PeriodCalendar, StubDrop, 0, 12, 352, 365, false},
+		{PeriodFiscal, StubDrop, 7, 11, 365, 365, false},
+		{PeriodFiscal, StubProrate, 7, 13, 168, 229, true},
+		{PeriodFiscal, StubMerge, 7, 11, 533, 594, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy+"/"+tt.stubs, func(t *testing.T) {
+			params := base
+			params.PeriodPolicy = tt.policy
+			params.StubHandling = tt.stubs
+			params.FiscalYearStartMonth = tt.fiscalMonth
+
+			result, err := Calculate(params)
+			if err != nil {
+				t.Fatalf("Calculate returned an error: %v", err)
+			}
+			if len(result.Periods) != tt.periods {
+				t.Fatalf("Expected %d periods, got %d", tt.periods, len(result.Periods))
+			}
+			first, last := result.Periods[0], result.Periods[len(result.Periods)-1]
+			if first.NumOfRunoutDays != tt.firstDays || last.NumOfRunoutDays != tt.lastDays {
+				t.Errorf("Expected first and last periods of %d and %d days, got %d and %d", tt.firstDays, tt.lastDays, first.NumOfRunoutDays, last.NumOfRunoutDays)
+			}
+
+			covered := 0
+			for i, period := range result.Periods {
+				covered += period.NumOfRunoutDays
+				if period.ContractYearNumber != i+1 {
+					t.Errorf("Expected contract year %d, got %d", i+1, period.ContractYearNumber)
+				}
+			}
+			if tt.coversContract && covered != contractDays {
+				t.Errorf("Expected the periods to cover all %d contract days, got %d", contractDays, covered)
+			}
+		})
+	}
+}
+
+func TestRunoutStubMinimum(t *testing.T) {
+	params := getTestParams()
+	params.ContractEndDate = civil.New(2027, 2, 14)
+	params.FlightHoursMinimum = 600
+	params.StubHandling = StubProrate
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// The 45 day stub is held to a pro-rated share of the annual minimum.
+	stub := result.Periods[len(result.Periods)-1]
+	if stub.NumOfRunoutDays != 45 {
+		t.Fatalf("Expected a 45 day stub, got %d days", stub.NumOfRunoutDays)
+	}
+	expected := params.FlightHoursMinimum*45/params.NumOfDaysInYear - stub.Engines[0].FHUtilization
+	if !almostEqual(stub.Engines[0].Shortfall, expected, 1e-9) {
+		t.Errorf("Stub shortfall incorrect. Expected %f, got %f", expected, stub.Engines[0].Shortfall)
+	}
+	if !almostEqual(result.Periods[0].Engines[0].Shortfall, 120, 1e-9) {
+		t.Errorf("Full year shortfall incorrect. Expected 120, got %f", result.Periods[0].Engines[0].Shortfall)
+	}
+}
+
+func TestRunoutPeriodPolicyValidate(t *testing.T) {
+	params := getTestParams()
+	params.PeriodPolicy = PeriodFiscal
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a fiscal policy without FiscalYearStartMonth")
+	}
+
+	params.PeriodPolicy = "quarterly"
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown PeriodPolicy")
+	}
+
+	params = getTestParams()
+	params.StubHandling = "round"
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown StubHandling")
+	}
+}
+
+func TestRunoutMonthlySchedule(t *testing.T) {
+	for _, stubs := range []string{StubDrop, StubProrate, StubMerge} {
+		t.Run(stubs, func(t *testing.T) {
+			params := getTestParams()
+			params.ContractStartDate = civil.New(2022, 1, 14)
+			params.ContractEndDate = civil.New(2034, 2, 14)
+			params.FlightHoursMinimum = 475
+			params.ShortfallBilling = ShortfallAnnual
+			params.StubHandling = stubs
+			params.IncludeMonthlySchedule = true
+
+			result, err := Calculate(params)
+			if err != nil {
+				t.Fatalf("Calculate returned an error: %v", err)
+			}
+
+			months := map[int][]ContractPeriod{}
+			for _, month := range result.MonthlySchedule {
+				if month.NumOfDays > 31 {
+					t.Errorf("Month starting %v has %d days", month.StartDate, month.NumOfDays)
+				}
+				months[month.ContractYearNumber] = append(months[month.ContractYearNumber], month)
+			}
+
+			for _, period := range result.Periods {
+				var days, warrantyDays, firstRunDays int
+				var fhRevenue, shortfall, mgmt, aic, trustLoad, trust, total float64
+				for _, month := range months[period.ContractYearNumber] {
+					days += month.NumOfRunoutDays
+					warrantyDays += month.Engines[0].Tiers[0].Days
+					firstRunDays += month.Engines[0].Tiers[1].Days
+					fhRevenue += month.TotalFHRevenue
+					shortfall += month.ShortfallRevenue
+					mgmt += month.MgmtFeeRevenue
+					aic += month.AICRevenue
+					trustLoad += month.TrustLoadRevenue
+					trust += month.TrustRevenue
+					total += month.TotalRevenue
+				}
+
+				if days != period.NumOfRunoutDays || warrantyDays != period.Engines[0].Tiers[0].Days || firstRunDays != period.Engines[0].Tiers[1].Days {
+					t.Errorf("Year %d days do not roll up: %d/%d/%d against %d/%d/%d", period.ContractYearNumber, days, warrantyDays, firstRunDays, period.NumOfRunoutDays, period.Engines[0].Tiers[0].Days, period.Engines[0].Tiers[1].Days)
+				}
+				sums := []struct {
+					name          string
+					month, period float64
+				}{
+					{"FH revenue", fhRevenue, period.TotalFHRevenue},
+					{"shortfall revenue", shortfall, period.ShortfallRevenue},
+					{"management fee", mgmt, period.MgmtFeeRevenue},
+					{"AIC revenue", aic, period.AICRevenue},
+					{"trust load revenue", trustLoad, period.TrustLoadRevenue},
+					{"trust revenue", trust, period.TrustRevenue},
+					{"total revenue", total, period.TotalRevenue},
+				}
+				for _, sum := range sums {
+					if !almostEqual(sum.month, sum.period, 1e-6) {
+						t.Errorf("Year %d %s does not roll up: months sum to %f, period has %f", period.ContractYearNumber, sum.name, sum.month, sum.period)
+					}
+				}
+			}
+
+			last := result.MonthlySchedule[len(result.MonthlySchedule)-1]
+			if !almostEqual(last.CumulativeTotalRevenue, result.CumulativeTotalRevenue, 1e-6) {
+				t.Errorf("Expected cumulative revenue %f, got %f", result.CumulativeTotalRevenue, last.CumulativeTotalRevenue)
+			}
+		})
+	}
+
+	// Billing months run NumOfDaysInMonth days from the start of the period,
+	// and the last one ends with it.
+	params := getTestParams()
+	params.FlightHoursMinimum = 600
+	params.ShortfallBilling = ShortfallAnnual
+	params.IncludeMonthlySchedule = true
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	first := result.Periods[0]
+	var months []ContractPeriod
+	for _, month := range result.MonthlySchedule {
+		if month.ContractYearNumber == first.ContractYearNumber {
+			months = append(months, month)
+		}
+	}
+	if len(months) != 13 {
+		t.Fatalf("Expected 13 billing months in the first year, got %d", len(months))
+	}
+	for m, month := range months[:12] {
+		if start := first.RunoutStartDate.AddDays(30 * m); month.StartDate != start || month.NumOfDays != 30 {
+			t.Errorf("Expected month %d to start on %v and run 30 days, got %v and %d days", m+1, start, month.StartDate, month.NumOfDays)
+		}
+	}
+	if last := months[12]; last.EndDate != first.RunoutEndDate || last.NumOfDays != 5 {
+		t.Errorf("Expected the last month to end on %v after 5 days, got %v after %d days", first.RunoutEndDate, last.EndDate, last.NumOfDays)
+	}
+
+	// Fees follow the revenue they are charged on, so the fees on the
+	// shortfall fall in the last month with it.
+	last := months[12]
+	if first.ShortfallRevenue == 0 {
+		t.Fatalf("Expected a shortfall in the first year")
+	}
+	if share := (last.TotalFHRevenue + last.ShortfallRevenue) / (first.TotalFHRevenue + first.ShortfallRevenue); !almostEqual(last.MgmtFeeRevenue, first.MgmtFeeRevenue*share, 1e-6) {
+		t.Errorf("Expected a management fee of %f in the last month, got %f", first.MgmtFeeRevenue*share, last.MgmtFeeRevenue)
+	}
+
+	// With NumOfDaysInMonth 0 the months are calendar months.
+	params.NumOfDaysInMonth = 0
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	periodEnds := map[int]civil.Date{}
+	for _, period := range result.Periods {
+		periodEnds[period.ContractYearNumber] = period.RunoutEndDate
+	}
+	for _, month := range result.MonthlySchedule {
+		if month.EndDate.AddDays(1).Day != 1 && month.EndDate != periodEnds[month.ContractYearNumber] {
+			t.Errorf("Expected the month from %v to end at a calendar month end, got %v", month.StartDate, month.EndDate)
+		}
+	}
+
+	params = getTestParams()
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	if result.MonthlySchedule != nil {
+		t.Errorf("Expected no monthly schedule unless requested, got %d months", len(result.MonthlySchedule))
+	}
+}
+
+func TestRunoutDayCountConvention(t *testing.T) {
+	params := getTestParams()
+	params.PeriodPolicy = PeriodCalendar
+
+	// 2024 is a leap year, the second contract year from 2023-01-01.
+	tests := []struct {
+		convention           string
+		days2023, days2024   int
+		hours2023, hours2024 float64
+	}{
+		{"", 365, 366, 480, 480 * 366.0 / 365},
+		{string(daycount.Act365Fixed), 365, 366, 480, 480 * 366.0 / 365},
+		{string(daycount.Act360), 365, 366, 480 * 365.0 / 360, 480 * 366.0 / 360},
+		{string(daycount.Thirty360), 360, 360, 480, 480},
+		{string(daycount.ActAct), 365, 366, 480, 480},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.convention, func(t *testing.T) {
+			params.DayCountConvention = tt.convention
+			params.IncludeMonthlySchedule = true
+			result, err := Calculate(params)
+			if err != nil {
+				t.Fatalf("Calculate returned an error: %v", err)
+			}
+
+			year2023, year2024 := result.Periods[0].Engines[0], result.Periods[1].Engines[0]
+			if year2023.TotalDays != tt.days2023 || year2024.TotalDays != tt.days2024 {
+				t.Errorf("Expected %d and %d rate days, got %d and %d", tt.days2023, tt.days2024, year2023.TotalDays, year2024.TotalDays)
+			}
+			if !almostEqual(year2023.FHUtilization, tt.hours2023, 1e-9) || !almostEqual(year2024.FHUtilization, tt.hours2024, 1e-9) {
+				t.Errorf("Expected %f and %f flight hours, got %f and %f", tt.hours2023, tt.hours2024, year2023.FHUtilization, year2024.FHUtilization)
+			}
+
+			// Both years bill the warranty rate for every hour flown.
+			expected := year2024.FHUtilization * params.WarrantyRate * result.Periods[1].RateTrend
+			if !almostEqual(year2024.FHRevenue, expected, 1e-6) {
+				t.Errorf("Expected %f revenue in 2024, got %f", expected, year2024.FHRevenue)
+			}
+
+			fhRevenue, hours := 0.0, 0.0
+			for _, month := range result.MonthlySchedule {
+				fhRevenue += month.TotalFHRevenue
+				hours += month.Engines[0].FHUtilization
+			}
+			total := 0.0
+			for _, period := range result.Periods {
+				total += period.Engines[0].FHUtilization
+			}
+			if !almostEqual(fhRevenue, result.TotalFHRevenue, 1e-6) || !almostEqual(hours, total, 1e-9) {
+				t.Errorf("Monthly schedule does not roll up: %f revenue and %f hours against %f and %f", fhRevenue, hours, result.TotalFHRevenue, total)
+			}
+		})
+	}
+
+	params.DayCountConvention = "ACT/364"
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown DayCountConvention")
+	}
+}
+
+func TestRunoutDayCountWarrantyHours(t *testing.T) {
+	params := getTestParams()
+	params.DayCountConvention = string(daycount.ActAct)
+	params.EngineParams[0].WarrantyExpHours = 960
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// Two ACT/ACT years of 480 hours end on the second anniversary, leap year or not.
+	expected := civil.New(2024, 12, 31)
+	if summary := result.Engines[0]; summary.Tiers[1].StartDate.AddDays(-1) != expected || summary.Tiers[1].Trigger != TriggerHours {
+		t.Errorf("Expected warranty end %v (hours), got %v (%s)", expected, summary.Tiers[1].StartDate.AddDays(-1), summary.Tiers[1].Trigger)
+	}
+}
+
+func TestRunoutRateTiers(t *testing.T) {
+	legacy := getTestParams()
+	legacyResult, err := Calculate(legacy)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// Tiers equivalent to the legacy fields give the same result.
+	params := getTestParams()
+	ep := params.EngineParams[0]
+	params.RateTiers = []RateTier{
+		{Name: "warranty", Rate: params.WarrantyRate},
+		{Name: "firstRun", Rate: params.FirstRunRate, EffectiveDate: ep.WarrantyExpDate.AddDays(1), Hours: ep.WarrantyExpHours},
+		{Name: "secondRun", Rate: params.SecondRunRate, EffectiveDate: ep.FirstRunRateSwitchDate.AddDays(1)},
+		{Name: "thirdRun", Rate: params.ThirdRunRate, EffectiveDate: ep.SecondRunRateSwitchDate.AddDays(1)},
+	}
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	if !almostEqual(result.TotalFHRevenue, legacyResult.TotalFHRevenue, 1e-6) {
+		t.Errorf("Expected tiers to match the legacy fields, got %f against %f", result.TotalFHRevenue, legacyResult.TotalFHRevenue)
+	}
+
+	// Six step-ups, the second engine with rates of its own.
+	params.RateTiers = []RateTier{
+		{Name: "warranty", Rate: 200},
+		{Name: "step 1", Rate: 220, EffectiveDate: civil.New(2023, 7, 1)},
+		{Name: "step 2", Rate: 240, EffectiveDate: civil.New(2024, 1, 1)},
+		{Name: "step 3", Rate: 260, EffectiveDate: civil.New(2024, 7, 1)},
+		{Name: "step 4", Rate: 280, EffectiveDate: civil.New(2025, 1, 1)},
+		{Name: "step 5", Rate: 300, EffectiveDate: civil.New(2026, 1, 1), Hours: 1500},
+	}
+	params.EngineParams[1].RateTiers = []RateTier{
+		{Name: "flat", Rate: 250},
+	}
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	year1 := result.Periods[0].Engines[0]
+	if len(year1.Tiers) != 6 || year1.Tiers[0].Days != 181 || year1.Tiers[1].Days != 184 || year1.Tiers[2].Days != 0 {
+		t.Errorf("Year 1 tier days incorrect, got %+v", year1.Tiers)
+	}
+	for _, tier := range year1.Tiers {
+		expected := tier.FlightHours * tier.Rate * result.Periods[0].RateTrend
+		if !almostEqual(tier.FHRevenue, expected, 1e-9) {
+			t.Errorf("Tier %s revenue incorrect. Expected %f, got %f", tier.Name, expected, tier.FHRevenue)
+		}
+	}
+
+	// 1500 hours at 480 a year are reached on 2026-02-14, after the step 5 date.
+	step5 := result.Engines[0].Tiers[5]
+	if step5.StartDate != civil.New(2026, 1, 1) || step5.Trigger != TriggerDate {
+		t.Errorf("Step 5 start incorrect. Expected 2026-01-01 (date), got %v (%s)", step5.StartDate, step5.Trigger)
+	}
+	params.EngineParams[0].TSNAtContractStart = 300
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	step5 = result.Engines[0].Tiers[5]
+	if step5.StartDate != civil.New(2025, 7, 2) || step5.Trigger != TriggerHours {
+		t.Errorf("Step 5 start incorrect. Expected 2025-07-02 (hours), got %v (%s)", step5.StartDate, step5.Trigger)
+	}
+	if days := result.Periods[2].Engines[0].Tiers[4].Days; days != 182 {
+		t.Errorf("Expected step 4 to end when step 5 starts, got %d days in 2025", days)
+	}
+
+	// Hours reached before step 4 starts move step 5 up to it, leaving step 4 no days.
+	params.EngineParams[0].TSNAtContractStart = 600
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	if step5 = result.Engines[0].Tiers[5]; step5.StartDate != civil.New(2025, 1, 1) {
+		t.Errorf("Step 5 start incorrect. Expected 2025-01-01, got %v", step5.StartDate)
+	}
+	if days := result.Periods[2].Engines[0].Tiers[4].Days; days != 0 {
+		t.Errorf("Expected no step 4 days, got %d", days)
+	}
+
+	flat := result.Periods[0].Engines[1]
+	if len(flat.Tiers) != 1 || flat.Tiers[0].Days != 365 || !almostEqual(flat.FHRevenue, 480*250, 1e-6) {
+		t.Errorf("Expected the engine's own flat rate, got %+v", flat.Tiers)
+	}
+}
+
+func TestRunoutRateTiersValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiers []RateTier
+	}{
+		{"negative rate", []RateTier{{Rate: -1}}},
+		{"first tier with hours", []RateTier{{Rate: 1, Hours: 100}}},
+		{"first tier after start", []RateTier{{Rate: 1, EffectiveDate: civil.New(2024, 1, 1)}}},
+		{"no trigger", []RateTier{{Rate: 1}, {Rate: 2}}},
+		{"dates out of order", []RateTier{{Rate: 1}, {Rate: 2, EffectiveDate: civil.New(2025, 1, 1)}, {Rate: 3, EffectiveDate: civil.New(2024, 1, 1)}}},
+		{"hours out of order", []RateTier{{Rate: 1}, {Rate: 2, Hours: 2000}, {Rate: 3, Hours: 1000}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := getTestParams()
+			params.RateTiers = tt.tiers
+			if err := params.Validate(); err == nil {
+				t.Errorf("Expected an error for contract tiers")
+			}
+
+			params = getTestParams()
+			params.EngineParams[1].RateTiers = tt.tiers
+			if err := params.Validate(); err == nil {
+				t.Errorf("Expected an error for engine tiers")
+			}
+		})
+	}
+
+	// Engines on tiers need none of the legacy switch dates.
+	params := getTestParams()
+	params.RateTiers = []RateTier{{Rate: 250}}
+	params.EngineParams[0] = EngineParams{SerialNumber: "1085718"}
+	if err := params.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestRunoutEngineOverrides(t *testing.T) {
+	params := getTestParams()
+	params.ShortfallBilling = ShortfallAnnual
+	auHours, minimum, warrantyRate := 240.0, 300.0, 300.0
+	leased := &params.EngineParams[1]
+	leased.AUHours = &auHours
+	leased.FlightHoursMinimum = &minimum
+	leased.WarrantyRate = &warrantyRate
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	owned, lease := result.Engines[0], result.Engines[1]
+	if owned.AUHours != 480 || owned.FlightHoursMinimum != 150 || owned.Tiers[0].Rate != 243.6 {
+		t.Errorf("Expected the contract values for engine 1, got %+v", owned)
+	}
+	if lease.AUHours != 240 || lease.FlightHoursMinimum != 300 || lease.Tiers[0].Rate != 300 || lease.Tiers[1].Rate != params.FirstRunRate {
+		t.Errorf("Expected the overrides for engine 2, got %+v", lease)
+	}
+
+	// At half the hours the leased engine stays on warranty for twice as long.
+	if lease.Tiers[1].StartDate.DaysSince(params.ContractStartDate) <= owned.Tiers[1].StartDate.DaysSince(params.ContractStartDate) {
+		t.Errorf("Expected the leased engine's warranty hours to be reached later, got %v against %v", lease.Tiers[1].StartDate, owned.Tiers[1].StartDate)
+	}
+
+	year1 := result.Periods[0]
+	if !almostEqual(year1.Engines[1].FHUtilization, 240, 1e-9) || !almostEqual(year1.Engines[1].FHRevenue, 240*300, 1e-6) {
+		t.Errorf("Expected 240 hours at 300 for engine 2, got %f hours and %f revenue", year1.Engines[1].FHUtilization, year1.Engines[1].FHRevenue)
+	}
+	if year1.Engines[0].Shortfall != 0 || !almostEqual(year1.Engines[1].Shortfall, 60, 1e-9) {
+		t.Errorf("Expected a 60 hour shortfall for engine 2 only, got %f and %f", year1.Engines[0].Shortfall, year1.Engines[1].Shortfall)
+	}
+	if year1.Engines[1].ShortfallRevenue <= 0 {
+		t.Errorf("Expected engine 2 to be billed for its shortfall")
+	}
+
+	negative := -1.0
+	params = getTestParams()
+	params.EngineParams[0].FirstRunRate = &negative
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a negative engine rate")
 	}
-}
\ No newline at end of file
+	zero := 0.0
+	params = getTestParams()
+	params.EngineParams[0].AUHours = &zero
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for engine AUHours of zero")
+	}
+
+	// Rate overrides cannot be combined with the rate tiers that replace them.
+	params = getTestParams()
+	params.EngineParams[0].WarrantyRate = &warrantyRate
+	params.RateTiers = []RateTier{{Name: "flat", Rate: 250}}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a rate override with contract rate tiers")
+	}
+	params.RateTiers = nil
+	params.EngineParams[0].RateTiers = []RateTier{{Name: "flat", Rate: 250}}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a rate override with engine rate tiers")
+	}
+}
+
+func TestRunoutEngineEvents(t *testing.T) {
+	params := getTestParams()
+	params.FlightHoursMinimum = 500
+	params.IncludeMonthlySchedule = true
+
+	// Engine 2 is leased out for the second half of 2025 and a third engine
+	// joins on 2024-04-01.
+	params.EngineParams[1].WarrantyExpDate = civil.New(2027, 12, 31)
+	params.EngineParams[1].WarrantyExpHours = 1200
+	params.EngineParams[1].Events = []EngineEvent{
+		{Type: EventRemove, Date: civil.New(2025, 7, 1)},
+		{Type: EventEnroll, Date: civil.New(2026, 1, 1)},
+	}
+	third := params.EngineParams[0]
+	third.SerialNumber = "1085720"
+	third.Position = 3
+	third.Events = []EngineEvent{{Type: EventEnroll, Date: civil.New(2024, 4, 1)}}
+	params.EngineParams = append(params.EngineParams, third)
+	params.NumEngines = 3
+
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	year2025 := result.Periods[2].Engines[1]
+	if len(year2025.Active) != 1 || year2025.Active[0].EndDate != civil.New(2025, 6, 30) || year2025.TotalDays != 181 {
+		t.Errorf("Expected engine 2 to be active until 2025-06-30, got %+v over %d days", year2025.Active, year2025.TotalDays)
+	}
+	if !almostEqual(year2025.FHUtilization, 480*181.0/365, 1e-9) {
+		t.Errorf("Expected %f hours for engine 2 in 2025, got %f", 480*181.0/365, year2025.FHUtilization)
+	}
+	// The minimum is pro-rated to the enrolled days as well.
+	if expected := 20 * 181.0 / 365; !almostEqual(year2025.Shortfall, expected, 1e-9) || !almostEqual(result.Periods[2].Engines[0].Shortfall, 20, 1e-9) {
+		t.Errorf("Expected shortfalls of 20 and %f hours, got %f and %f", expected, result.Periods[2].Engines[0].Shortfall, year2025.Shortfall)
+	}
+	if result.Periods[3].Engines[1].TotalDays != 365 {
+		t.Errorf("Expected engine 2 to be active all of 2026, got %d days", result.Periods[3].Engines[1].TotalDays)
+	}
+
+	// Hours do not accrue while the engine is away, so 1200 hours are
+	// reached after it returns.
+	firstRun := result.Engines[1].Tiers[1]
+	if firstRun.Trigger != TriggerHours || firstRun.StartDate.Before(civil.New(2026, 1, 1)) || !firstRun.StartDate.Before(civil.New(2026, 2, 1)) {
+		t.Errorf("Expected engine 2's warranty hours to be reached in January 2026, got %v (%s)", firstRun.StartDate, firstRun.Trigger)
+	}
+
+	year2023, year2024 := result.Periods[0].Engines[2], result.Periods[1].Engines[2]
+	if len(year2023.Active) != 0 || year2023.TotalDays != 0 || year2023.FHRevenue != 0 || year2023.Shortfall != 0 {
+		t.Errorf("Expected engine 3 to be inactive in 2023, got %+v", year2023)
+	}
+	if year2024.TotalDays != 275 || year2024.Active[0].StartDate != civil.New(2024, 4, 1) {
+		t.Errorf("Expected engine 3 to be active from 2024-04-01, got %+v over %d days", year2024.Active, year2024.TotalDays)
+	}
+
+	// Each engine pays a third of the buy-in, pro-rated to its enrolled days,
+	// in the year it enrolls.
+	contractDays := float64(daysBetween(params.ContractStartDate, params.ContractEndDate))
+	share := params.BuyIn / 3
+	expected2023 := share + share*(contractDays-184)/contractDays
+	expected2024 := share * float64(daysBetween(civil.New(2024, 4, 1), params.ContractEndDate)) / contractDays
+	if !almostEqual(result.Periods[0].BuyIn, expected2023, 1e-6) || !almostEqual(result.Periods[1].BuyIn, expected2024, 1e-6) {
+		t.Errorf("Expected buy-ins of %f and %f, got %f and %f", expected2023, expected2024, result.Periods[0].BuyIn, result.Periods[1].BuyIn)
+	}
+	if !almostEqual(result.BuyIn, expected2023+expected2024, 1e-6) {
+		t.Errorf("Expected a total buy-in of %f, got %f", expected2023+expected2024, result.BuyIn)
+	}
+	for _, month := range result.MonthlySchedule {
+		if month.ContractYearNumber != 2 {
+			continue
+		}
+		expected := 0.0
+		if enrolled := civil.New(2024, 4, 1); !enrolled.Before(month.StartDate) && !month.EndDate.Before(enrolled) {
+			expected = expected2024
+		}
+		if !almostEqual(month.BuyIn, expected, 1e-6) {
+			t.Errorf("Expected a buy-in of %f in the month from %v, got %f", expected, month.StartDate, month.BuyIn)
+		}
+	}
+
+	// An engine removed before the last year is billed its end-of-term
+	// shortfall in the last year it is enrolled.
+	removed := getTestParams()
+	removed.FlightHoursMinimum = 500
+	removed.ShortfallBilling = ShortfallEndOfTerm
+	removed.EngineParams[1].Events = []EngineEvent{{Type: EventRemove, Date: civil.New(2026, 1, 1)}}
+	removedResult, err := Calculate(removed)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	outstanding := 0.0
+	for _, period := range removedResult.Periods[:3] {
+		outstanding += removed.FlightHoursMinimum - period.Engines[1].FHUtilization
+	}
+	year2025 = removedResult.Periods[2].Engines[1]
+	if expected := outstanding * hourlyRate(year2025); expected == 0 || !almostEqual(year2025.ShortfallRevenue, expected, 1e-6) {
+		t.Errorf("Expected an end-of-term shortfall of %f for the removed engine in 2025, got %f", expected, year2025.ShortfallRevenue)
+	}
+
+	// The buy-in is pro-rated in the contract's day-count convention.
+	params.DayCountConvention = string(daycount.Thirty360)
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	expected2024 = share * (10*360 + 270) / (12 * 360)
+	if !almostEqual(result.Periods[1].BuyIn, expected2024, 1e-6) {
+		t.Errorf("Expected a 30/360 buy-in of %f, got %f", expected2024, result.Periods[1].BuyIn)
+	}
+}
+
+func TestRunoutEngineEventsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []EngineEvent
+	}{
+		{"unknown type", []EngineEvent{{Type: "swap", Date: civil.New(2025, 1, 1)}}},
+		{"before the contract", []EngineEvent{{Type: EventEnroll, Date: civil.New(2022, 12, 31)}}},
+		{"after the contract", []EngineEvent{{Type: EventRemove, Date: civil.New(2035, 1, 1)}}},
+		{"out of order", []EngineEvent{{Type: EventRemove, Date: civil.New(2025, 1, 1)}, {Type: EventEnroll, Date: civil.New(2024, 1, 1)}}},
+		{"removed twice", []EngineEvent{{Type: EventRemove, Date: civil.New(2025, 1, 1)}, {Type: EventRemove, Date: civil.New(2026, 1, 1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := getTestParams()
+			params.EngineParams[1].Events = tt.events
+			if err := params.Validate(); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRunoutTrueUp(t *testing.T) {
+	params := getTestParams()
+	forecast, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// Six months of actuals: engine 1 flies 50 hours a month, engine 2 30.
+	var csvRows strings.Builder
+	csvRows.WriteString("month,serialNumber,hours\n")
+	for month := time.January; month <= time.June; month++ {
+		params.Actuals = append(params.Actuals,
+			ActualHours{SerialNumber: "1085718", Month: civil.New(2023, month, 1), Hours: 50},
+			ActualHours{SerialNumber: "1085719", Month: civil.New(2023, month, 1), Hours: 30},
+		)
+		fmt.Fprintf(&csvRows, "2023-%02d,1085718,50\n2023-%02d,1085719,30\n", int(month), int(month))
+	}
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	forecastHours := 480 * 181.0 / 365
+	year1 := result.Periods[0]
+	if expected := 300 + 480*184.0/365; !almostEqual(year1.Engines[0].FHUtilization, expected, 1e-9) {
+		t.Errorf("Expected actuals for the first half of 2023 and the forecast after, got %f hours instead of %f", year1.Engines[0].FHUtilization, expected)
+	}
+	if !almostEqual(result.Periods[1].Engines[0].FHUtilization, forecast.Periods[1].Engines[0].FHUtilization, 1e-9) {
+		t.Errorf("Expected the forecast for 2024, got %f hours", result.Periods[1].Engines[0].FHUtilization)
+	}
+
+	trueUp := result.TrueUp
+	if trueUp == nil {
+		t.Fatalf("Expected a true-up statement")
+	}
+	if trueUp.ActualsThrough != civil.New(2023, 6, 30) {
+		t.Errorf("Expected actuals through 2023-06-30, got %v", trueUp.ActualsThrough)
+	}
+	engine := trueUp.Engines[0]
+	if !almostEqual(engine.BilledHours, forecastHours, 1e-9) || !almostEqual(engine.ActualHours, 300, 1e-9) {
+		t.Errorf("Expected %f billed and 300 actual hours, got %f and %f", forecastHours, engine.BilledHours, engine.ActualHours)
+	}
+	if !almostEqual(engine.Variance, (300-forecastHours)*params.WarrantyRate, 1e-6) {
+		t.Errorf("Expected a variance of %f, got %f", (300-forecastHours)*params.WarrantyRate, engine.Variance)
+	}
+	if !almostEqual(trueUp.Variance, (480-2*forecastHours)*params.WarrantyRate, 1e-6) {
+		t.Errorf("Expected a total variance of %f, got %f", (480-2*forecastHours)*params.WarrantyRate, trueUp.Variance)
+	}
+	if !almostEqual(trueUp.ForecastTotalRevenue, forecast.TotalRevenue, 1e-6) {
+		t.Errorf("Expected a forecast total of %f, got %f", forecast.TotalRevenue, trueUp.ForecastTotalRevenue)
+	}
+
+	// The extra hours bring the end of engine 1's warranty forward.
+	if !result.Engines[0].Tiers[1].StartDate.Before(forecast.Engines[0].Tiers[1].StartDate) {
+		t.Errorf("Expected the warranty hours to be reached before %v, got %v", forecast.Engines[0].Tiers[1].StartDate, result.Engines[0].Tiers[1].StartDate)
+	}
+
+	// The same actuals as CSV give the same statement.
+	params.Actuals = nil
+	params.ActualsCSV = csvRows.String()
+	fromCSV, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	if !almostEqual(fromCSV.TrueUp.Variance, trueUp.Variance, 1e-9) || !almostEqual(fromCSV.TotalRevenue, result.TotalRevenue, 1e-6) {
+		t.Errorf("Expected CSV actuals to match JSON actuals, got a variance of %f", fromCSV.TrueUp.Variance)
+	}
+
+	// A contract starting mid-month is trued up from its first day.
+	midMonth := getTestParams()
+	midMonth.ContractStartDate = civil.New(2022, 1, 14)
+	for month := time.January; month <= time.March; month++ {
+		midMonth.Actuals = append(midMonth.Actuals, ActualHours{SerialNumber: "1085718", Month: civil.New(2022, month, 1), Hours: 40})
+	}
+	result, err = Calculate(midMonth)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	engine = result.TrueUp.Engines[0]
+	if expected := 480 * 77.0 / 365; !almostEqual(engine.BilledHours, expected, 1e-9) || !almostEqual(engine.ActualHours, 120, 1e-9) {
+		t.Errorf("Expected %f billed and 120 actual hours, got %f and %f", expected, engine.BilledHours, engine.ActualHours)
+	}
+
+	var actual ActualHours
+	if err := json.Unmarshal([]byte(`{"serialNumber": "1085718", "month": "2023-03", "hours": 41.5}`), &actual); err != nil || actual.Month != civil.New(2023, 3, 1) {
+		t.Errorf("Expected a YYYY-MM month to parse, got %v (%v)", actual.Month, err)
+	}
+}
+
+func TestRunoutActualsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		actuals []ActualHours
+		csv     string
+	}{
+		{"unknown engine", []ActualHours{{SerialNumber: "999", Month: civil.New(2023, 1, 1), Hours: 10}}, ""},
+		{"negative hours", []ActualHours{{SerialNumber: "1085718", Month: civil.New(2023, 1, 1), Hours: -1}}, ""},
+		{"duplicate month", []ActualHours{{SerialNumber: "1085718", Month: civil.New(2023, 1, 1)}, {SerialNumber: "1085718", Month: civil.New(2023, 1, 31)}}, ""},
+		{"before the contract", []ActualHours{{SerialNumber: "1085718", Month: civil.New(2022, 12, 1), Hours: 10}}, ""},
+		{"missing column", nil, "serialNumber,hours\n1085718,10\n"},
+		{"invalid hours", nil, "serialNumber,month,hours\n1085718,2023-01,ten\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := getTestParams()
+			params.Actuals = tt.actuals
+			params.ActualsCSV = tt.csv
+			if err := params.Validate(); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRunoutFeeWaterfall(t *testing.T) {
+	params := getTestParams()
+	result, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// By default AIC and trust load are taken net of the management fee.
+	year1 := result.Periods[0]
+	gross := year1.TotalFHRevenue
+	net := gross * (1 - params.ManagementFees/100)
+	if len(year1.Waterfall) != 3 || year1.Waterfall[1].Name != FeeAIC || !almostEqual(year1.Waterfall[1].Base, net, 1e-6) {
+		t.Fatalf("Expected the AIC fee to be taken from %f, got %+v", net, year1.Waterfall)
+	}
+	if !almostEqual(year1.AICRevenue, net*params.AICFees/100, 1e-6) || !almostEqual(year1.TrustLoadRevenue, net*params.TrustLoadFees/100, 1e-6) {
+		t.Errorf("Expected AIC of %f and trust load of %f, got %f and %f", net*params.AICFees/100, net*params.TrustLoadFees/100, year1.AICRevenue, year1.TrustLoadRevenue)
+	}
+	fees := year1.MgmtFeeRevenue + year1.AICRevenue + year1.TrustLoadRevenue
+	if !almostEqual(year1.TrustRevenue, gross-fees-year1.BuyIn, 1e-6) || !almostEqual(year1.TotalRevenue, gross, 1e-6) {
+		t.Errorf("Expected trust revenue %f and total revenue %f, got %f and %f", gross-fees-year1.BuyIn, gross, year1.TrustRevenue, year1.TotalRevenue)
+	}
+
+	// A fixed fee first, the management fee on what is left, and a capped AIC.
+	params.FeeWaterfall = []Fee{
+		{Name: "administration", Amount: 10000},
+		{Name: FeeManagement, Base: FeeBaseNet, Percent: 15},
+		{Name: FeeAIC, NetOf: []string{FeeManagement}, Percent: 20, Cap: 30000},
+		{Name: FeeTrustLoad, Base: FeeBaseNet, Percent: 2.98},
+	}
+	result, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	year1 = result.Periods[0]
+	management := (gross - 10000) * 0.15
+	trustLoad := (gross - 10000 - management - 30000) * 0.0298
+	expected := []float64{10000, management, 30000, trustLoad}
+	for i, line := range year1.Waterfall {
+		if !almostEqual(line.Amount, expected[i], 1e-6) {
+			t.Errorf("Fee %s incorrect. Expected %f, got %f", line.Name, expected[i], line.Amount)
+		}
+	}
+	if !almostEqual(year1.MgmtFeeRevenue, management, 1e-6) || year1.AICRevenue != 30000 {
+		t.Errorf("Expected the named fees to be reported, got %f and %f", year1.MgmtFeeRevenue, year1.AICRevenue)
+	}
+	if expected := gross - 10000 - management - 30000 - trustLoad - year1.BuyIn; !almostEqual(year1.TrustRevenue, expected, 1e-6) {
+		t.Errorf("Expected trust revenue %f, got %f", expected, year1.TrustRevenue)
+	}
+	if !almostEqual(result.Waterfall[0].Amount, 10000*float64(len(result.Periods)), 1e-6) {
+		t.Errorf("Expected the fixed fee every year, got %f in total", result.Waterfall[0].Amount)
+	}
+}
+
+func TestRunoutFeeWaterfallValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		fees []Fee
+	}{
+		{"no name", []Fee{{Percent: 10}}},
+		{"duplicate name", []Fee{{Name: "a", Percent: 10}, {Name: "a", Percent: 5}}},
+		{"unknown base", []Fee{{Name: "a", Base: "profit", Percent: 10}}},
+		{"net of a later fee", []Fee{{Name: "a", NetOf: []string{"b"}, Percent: 10}, {Name: "b", Percent: 5}}},
+		{"percent over 100", []Fee{{Name: "a", Percent: 120}}},
+		{"percent and amount", []Fee{{Name: "a", Percent: 10, Amount: 100}}},
+		{"negative cap", []Fee{{Name: "a", Percent: 10, Cap: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := getTestParams()
+			params.FeeWaterfall = tt.fees
+			if err := params.Validate(); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRunoutGoalSeek(t *testing.T) {
+	params := getTestParams()
+	base, err := Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+
+	// The run rate that brings in 10% more over the contract.
+	target := base.CumulativeTotalRevenue * 1.1
+	engine := NewGoalSeekCalculator(GoalSeekParams{Params: params, Target: target})
+	if err := engine.Validate(); err != nil {
+		t.Fatalf("Validate returned an error: %v", err)
+	}
+	if err := engine.Compute(); err != nil {
+		t.Fatalf("Compute returned an error: %v", err)
+	}
+	result, ok := engine.GetResult().(GoalSeekResult)
+	if !ok {
+		t.Fatalf("GetResult did not return a GoalSeekResult")
+	}
+	if result.SolveFor != SolveForRunRate || result.SeekTarget != SeekCumulativeTotalRevenue {
+		t.Errorf("Expected the default run rate and cumulative revenue, got %s and %s", result.SolveFor, result.SeekTarget)
+	}
+	if !almostEqual(result.Result.CumulativeTotalRevenue, target, 1e-6) {
+		t.Errorf("Expected cumulative revenue %f, got %f", target, result.Result.CumulativeTotalRevenue)
+	}
+	if result.FirstRunRate <= params.FirstRunRate || result.ThirdRunRate != result.FirstRunRate || result.WarrantyRate != params.WarrantyRate {
+		t.Errorf("Expected a higher run rate and the same warranty rate, got %+v", result)
+	}
+	if rate := result.Result.Engines[0].Tiers[3].Rate; rate != result.SolvedValue {
+		t.Errorf("Expected the engines to bill the solved rate %f, got %f", result.SolvedValue, rate)
+	}
+
+	// Without a buy-in, trust revenue scales with every rate, so 20% more
+	// needs a multiplier of 1.2.
+	params.BuyIn = 0
+	params.EngineParams[1].RateTiers = []RateTier{{Name: "flat", Rate: 250}}
+	base, err = Calculate(params)
+	if err != nil {
+		t.Fatalf("Calculate returned an error: %v", err)
+	}
+	result, err = SolveRates(GoalSeekParams{
+		Params:     params,
+		SolveFor:   SolveForRateMultiplier,
+		SeekTarget: SeekTrustRevenue,
+		Target:     base.TrustRevenue * 1.2,
+	})
+	if err != nil {
+		t.Fatalf("SolveRates returned an error: %v", err)
+	}
+	if !almostEqual(result.SolvedValue, 1.2, 1e-9) || !almostEqual(result.Result.Engines[1].Tiers[0].Rate, 300, 1e-6) {
+		t.Errorf("Expected a multiplier of 1.2 and an engine rate of 300, got %f and %f", result.SolvedValue, result.Result.Engines[1].Tiers[0].Rate)
+	}
+	if params.EngineParams[1].RateTiers[0].Rate != 250 {
+		t.Errorf("Expected the request's rates to be left alone")
+	}
+
+	// Targets only negative rates would reach have no solution: the warranty
+	// years alone bill more than 400000, and the trust pays the buy-in
+	// whatever the rates.
+	unreachable := []GoalSeekParams{
+		{Params: getTestParams(), SolveFor: SolveForRunRate, Target: 400000},
+		{Params: getTestParams(), SolveFor: SolveForRateMultiplier, SeekTarget: SeekTrustRevenue, Target: -2000000},
+	}
+	for _, gs := range unreachable {
+		if _, err := SolveRates(gs); !errors.Is(err, financials.ErrNoSolution) {
+			t.Errorf("Expected no solution solving for %s, got %v", gs.SolveFor, err)
+		}
+	}
+}
+
+func TestRunoutGoalSeekValidate(t *testing.T) {
+	params := GoalSeekParams{Params: getTestParams(), SolveFor: "warrantyRate"}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown SolveFor")
+	}
+
+	params = GoalSeekParams{Params: getTestParams(), SeekTarget: "profit"}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown SeekTarget")
+	}
+
+	params = GoalSeekParams{Params: getTestParams()}
+	params.Params.RateTiers = []RateTier{{Rate: 250}}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a run rate with rate tiers")
+	}
+	params.SolveFor = SolveForRateMultiplier
+	if err := params.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	rate := 260.0
+	params = GoalSeekParams{Params: getTestParams()}
+	params.Params.EngineParams[0].FirstRunRate = &rate
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a run rate with an engine rate override")
+	}
+	params = GoalSeekParams{Params: getTestParams()}
+	params.Params.EngineParams[1].RateTiers = []RateTier{{Rate: 250}}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a run rate with engine rate tiers")
+	}
+}
+
+func TestRunoutScenarioTermination(t *testing.T) {
+	params := getTestParams()
+	termination := &Termination{Date: civil.New(2029, 1, 1), FeeType: TerminationFeeFlat, Amount: 50000}
+	engine := NewScenarioCalculator(ScenarioParams{Params: params, Termination: termination})
+	if err := engine.Validate(); err != nil {
+		t.Fatalf("Validate returned an error: %v", err)
+	}
+	if err := engine.Compute(); err != nil {
+		t.Fatalf("Compute returned an error: %v", err)
+	}
+	result, ok := engine.GetResult().(ScenarioResult)
+	if !ok {
+		t.Fatalf("GetResult did not return a ScenarioResult")
+	}
+
+	if len(result.Base.Periods) != 12 || len(result.Modified.Periods) != 6 || len(result.Deltas) != 12 {
+		t.Fatalf("Expected 12 base periods, 6 modified periods and 12 deltas, got %d, %d and %d", len(result.Base.Periods), len(result.Modified.Periods), len(result.Deltas))
+	}
+	last := result.Modified.Periods[5]
+	if last.EndDate != civil.New(2028, 12, 31) || last.TerminationFee != 50000 {
+		t.Errorf("Expected the fee in a last period ending 2028-12-31, got %s and %f", last.EndDate, last.TerminationFee)
+	}
+	// The buy-in is still amortized over the original term, so the
+	// terminated contract charges only the share of its six years.
+	expectedBuyIn := params.BuyIn * params.YearFraction(params.ContractStartDate, civil.New(2028, 12, 31)) /
+		params.YearFraction(params.ContractStartDate, params.ContractEndDate)
+	if !almostEqual(result.Modified.BuyIn, expectedBuyIn, 1e-6) || !almostEqual(result.Base.BuyIn, params.BuyIn, 1e-6) {
+		t.Errorf("Expected a buy-in of %f against %f, got %f against %f", expectedBuyIn, params.BuyIn, result.Modified.BuyIn, result.Base.BuyIn)
+	}
+	// The buy-in comes out of the trust revenue.
+	if expected := params.BuyIn - expectedBuyIn; !almostEqual(result.Deltas[0].TrustRevenue, expected, 1e-6) {
+		t.Errorf("Year 1: expected a trust revenue delta of %f, got %f", expected, result.Deltas[0].TrustRevenue)
+	}
+	for i, delta := range result.Deltas {
+		expected := 0.0
+		switch {
+		case i == 5:
+			expected = 50000
+		case i > 5:
+			expected = -result.Base.Periods[i].TotalRevenue
+		}
+		if !almostEqual(delta.TotalRevenue, expected, 1e-6) {
+			t.Errorf("Year %d: expected a total revenue delta of %f, got %f", delta.ContractYearNumber, expected, delta.TotalRevenue)
+		}
+	}
+	if !almostEqual(result.TotalRevenueDelta, result.Modified.TotalRevenue-result.Base.TotalRevenue, 1e-6) ||
+		!almostEqual(result.Modified.TotalRevenue, result.Modified.CumulativeTotalRevenue, 1e-6) {
+		t.Errorf("Expected the fee in the modified totals, got %+v", result.Modified)
+	}
+
+	// The fee on the remaining value takes a share of the revenue lost.
+	termination.FeeType, termination.Percent = TerminationFeeRemainingValue, 10
+	result, err := CalculateScenario(ScenarioParams{Params: params, Termination: termination})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+	remaining := 0.0
+	for _, period := range result.Base.Periods[6:] {
+		remaining += period.TotalFHRevenue
+	}
+	if !almostEqual(result.TerminationFee, remaining*0.1, 1e-6) {
+		t.Errorf("Expected a fee of %f, got %f", remaining*0.1, result.TerminationFee)
+	}
+
+	// The unrecovered buy-in is the part the terminated contract no longer
+	// charges, so the buy-in is billed once in all.
+	termination.FeeType = TerminationFeeUnrecoveredBuyIn
+	result, err = CalculateScenario(ScenarioParams{Params: params, Termination: termination})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+	if expected := params.BuyIn - expectedBuyIn; !almostEqual(result.TerminationFee, expected, 1e-6) {
+		t.Errorf("Expected a fee of %f, got %f", expected, result.TerminationFee)
+	}
+	if !almostEqual(result.Modified.BuyIn+result.TerminationFee, params.BuyIn, 1e-6) {
+		t.Errorf("Expected the buy-in and the fee to add up to %f, got %f", params.BuyIn, result.Modified.BuyIn+result.TerminationFee)
+	}
+
+	// A termination mid-year bills the part year even though stubs are
+	// dropped.
+	termination.Date, termination.FeeType = civil.New(2028, 7, 1), TerminationFeeNone
+	result, err = CalculateScenario(ScenarioParams{Params: params, Termination: termination})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+	if len(result.Modified.Periods) != 6 {
+		t.Fatalf("Expected 6 modified periods, got %d", len(result.Modified.Periods))
+	}
+	last = result.Modified.Periods[5]
+	if last.StartDate != civil.New(2028, 1, 1) || last.EndDate != civil.New(2028, 6, 30) || last.TotalFHRevenue <= 0 {
+		t.Errorf("Expected a billed part year from 2028-01-01 to 2028-06-30, got %s to %s billing %f", last.StartDate, last.EndDate, last.TotalFHRevenue)
+	}
+
+	// A termination in the first year bills the part of it that runs, and
+	// the fee with it.
+	params.ContractStartDate, params.ContractEndDate = civil.New(2022, 1, 14), civil.New(2034, 2, 14)
+	termination.Date, termination.FeeType = civil.New(2022, 6, 1), TerminationFeeUnrecoveredBuyIn
+	result, err = CalculateScenario(ScenarioParams{Params: params, Termination: termination})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+	if len(result.Modified.Periods) != 1 {
+		t.Fatalf("Expected 1 modified period, got %d", len(result.Modified.Periods))
+	}
+	first := result.Modified.Periods[0]
+	if first.StartDate != civil.New(2022, 1, 14) || first.EndDate != civil.New(2022, 5, 31) || first.TotalFHRevenue <= 0 {
+		t.Errorf("Expected a billed part year from 2022-01-14 to 2022-05-31, got %s to %s billing %f", first.StartDate, first.EndDate, first.TotalFHRevenue)
+	}
+	if expected := first.TotalFHRevenue + first.ShortfallRevenue + result.TerminationFee; result.TerminationFee <= 0 || !almostEqual(result.Modified.TotalRevenue, expected, 1e-6) {
+		t.Errorf("Expected the fee of %f in a total revenue of %f, got %f", result.TerminationFee, expected, result.Modified.TotalRevenue)
+	}
+}
+
+func TestRunoutScenarioExtension(t *testing.T) {
+	params := getTestParams()
+	rate, escalation := 300.0, 3.0
+	result, err := CalculateScenario(ScenarioParams{
+		Params:    params,
+		Extension: &Extension{EndDate: civil.New(2036, 12, 31), Rate: &rate, RateEscalation: &escalation},
+	})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+
+	if len(result.Modified.Periods) != 14 || len(result.Deltas) != 14 {
+		t.Fatalf("Expected 14 modified periods and deltas, got %d and %d", len(result.Modified.Periods), len(result.Deltas))
+	}
+	for _, delta := range result.Deltas[:12] {
+		if !almostEqual(delta.TotalFHRevenue, 0, 1e-6) {
+			t.Errorf("Year %d: expected no change before the extension, got %f", delta.ContractYearNumber, delta.TotalFHRevenue)
+		}
+	}
+	for i, expected := range []float64{300, 309} {
+		period := result.Modified.Periods[12+i]
+		if rate := hourlyRate(period.Engines[0]); !almostEqual(rate, expected, 1e-9) {
+			t.Errorf("Year %d: expected an hourly rate of %f, got %f", 13+i, expected, rate)
+		}
+		if !almostEqual(result.Deltas[12+i].TotalRevenue, period.TotalRevenue, 1e-6) {
+			t.Errorf("Year %d: expected the whole year as the delta, got %f", 13+i, result.Deltas[12+i].TotalRevenue)
+		}
+	}
+	if params.EngineParams[0].RateTiers != nil || params.RateEscalationByYear != nil {
+		t.Errorf("Expected the request's params to be left alone")
+	}
+
+	// A rate switch after the original end gives way to the extension rate.
+	switched := getTestParams()
+	for i := range switched.EngineParams {
+		switched.EngineParams[i].SecondRunRateSwitchDate = civil.New(2035, 6, 1)
+		switched.EngineParams[i].ThirdRunRateSwitchDate = civil.New(2035, 6, 1)
+	}
+	result, err = CalculateScenario(ScenarioParams{
+		Params:    switched,
+		Extension: &Extension{EndDate: civil.New(2036, 12, 31), Rate: &rate, RateEscalation: &escalation},
+	})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+	for i, expected := range []float64{300, 309} {
+		if rate := hourlyRate(result.Modified.Periods[12+i].Engines[0]); !almostEqual(rate, expected, 1e-9) {
+			t.Errorf("Year %d: expected an hourly rate of %f, got %f", 13+i, expected, rate)
+		}
+	}
+
+	// Without a new rate the contract rates run on at the contract escalation.
+	result, err = CalculateScenario(ScenarioParams{Params: params, Extension: &Extension{EndDate: civil.New(2035, 12, 31)}})
+	if err != nil {
+		t.Fatalf("CalculateScenario returned an error: %v", err)
+	}
+	expected := params.ThirdRunRate * math.Pow(1.0875, 12)
+	if rate := hourlyRate(result.Modified.Periods[12].Engines[0]); !almostEqual(rate, expected, 1e-6) {
+		t.Errorf("Expected an hourly rate of %f, got %f", expected, rate)
+	}
+}
+
+func TestRunoutScenarioValidate(t *testing.T) {
+	params := ScenarioParams{Params: getTestParams()}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a scenario without a termination or an extension")
+	}
+
+	params.Termination = &Termination{Date: civil.New(2035, 1, 1)}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a termination after the contract end")
+	}
+
+	// The stub from 2034-01-01 is dropped, so the contract bills through
+	// 2033-12-31 and a termination must fall on or before it.
+	stub := params
+	stub.Params.ContractStartDate, stub.Params.ContractEndDate = civil.New(2022, 1, 14), civil.New(2034, 2, 14)
+	for _, date := range []civil.Date{civil.New(2034, 2, 14), civil.New(2034, 1, 1)} {
+		stub.Termination = &Termination{Date: date}
+		if err := stub.Validate(); err == nil {
+			t.Errorf("Expected an error for a termination on %s, in the dropped stub", date)
+		}
+	}
+	stub.Termination = &Termination{Date: civil.New(2033, 12, 31)}
+	if err := stub.Validate(); err != nil {
+		t.Errorf("Expected a termination on the last billed day to be valid, got %v", err)
+	}
+	params.Termination = &Termination{Date: civil.New(2029, 1, 1), FeeType: "penalty"}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an unknown FeeType")
+	}
+	params.Termination = &Termination{Date: civil.New(2029, 1, 1), FeeType: TerminationFeeRemainingValue, Percent: 120}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a Percent above 100")
+	}
+	params.Termination = &Termination{Date: civil.New(2029, 1, 1)}
+	params.Params.EngineParams[1].Events = []EngineEvent{{Type: EventEnroll, Date: civil.New(2030, 1, 1)}}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for a termination before an engine enrolls")
+	}
+
+	params = ScenarioParams{Params: getTestParams(), Extension: &Extension{EndDate: civil.New(2034, 6, 30)}}
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for an extension ending before the contract end")
+	}
+	params.Termination = &Termination{Date: civil.New(2029, 1, 1)}
+	params.Extension.EndDate = civil.New(2036, 12, 31)
+	if err := params.Validate(); err == nil {
+		t.Errorf("Expected an error for both a termination and an extension")
+	}
+}
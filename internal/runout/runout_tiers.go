@@ -0,0 +1,159 @@
+package runout
+
+import (
+	"financialapi/internal/civil"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RateTier is a rate per flight hour that applies from EffectiveDate, or
+// from the day after the engine's TSN reaches Hours when that comes first,
+// until the next tier starts. The first tier applies from the contract
+// start. A zero EffectiveDate or Hours sets no trigger of that kind.
+type RateTier struct {
+	Name          string     `json:"name"`
+	EffectiveDate civil.Date `json:"effectiveDate"`
+	Hours         float64    `json:"hours"`
+	Rate          float64    `json:"rate"`
+}
+
+// Rate tier start triggers reported in TierSummary.Trigger.
+const (
+	TriggerContractStart = "contractStart"
+	TriggerDate          = "date"
+	TriggerHours         = "hours"
+)
+
+// TierSummary reports the day a tier starts for an engine and whether its
+// EffectiveDate or its Hours was reached first.
+type TierSummary struct {
+	Name      string
+	Rate      float64
+	StartDate civil.Date
+	Trigger   string
+}
+
+// TierData holds an engine's days and revenue at one rate tier in a period.
+type TierData struct {
+	Name        string
+	Rate        float64
+	Days        int
+	Calc        float64
+	FlightHours float64
+	FHRevenue   float64
+
+	// windows are the runs of days the engine spends at the tier.
+	windows []ActiveWindow
+}
+
+// legacyRateTiers maps the fixed warranty and run rate fields onto tiers,
+// taking the engine's own rates where it has them. The warranty rate ends at
+// WarrantyExpDate or WarrantyExpHours, and each run rate applies from the
+// day after the previous switch date.
+func legacyRateTiers(params RunoutParams, engineParams EngineParams) []RateTier {
+	return []RateTier{
+		{Name: "warranty", Rate: valueOr(engineParams.WarrantyRate, params.WarrantyRate)},
+		{Name: "firstRun", Rate: valueOr(engineParams.FirstRunRate, params.FirstRunRate), EffectiveDate: engineParams.WarrantyExpDate.AddDays(1), Hours: engineParams.WarrantyExpHours},
+		{Name: "secondRun", Rate: valueOr(engineParams.SecondRunRate, params.SecondRunRate), EffectiveDate: engineParams.FirstRunRateSwitchDate.AddDays(1)},
+		{Name: "thirdRun", Rate: valueOr(engineParams.ThirdRunRate, params.ThirdRunRate), EffectiveDate: engineParams.SecondRunRateSwitchDate.AddDays(1)},
+	}
+}
+
+// engineRateTiers returns the tiers of an engine: its own RateTiers, the
+// contract's RateTiers, or the legacy rate fields, in that order.
+func engineRateTiers(params RunoutParams, engineParams EngineParams) []RateTier {
+	if len(engineParams.RateTiers) > 0 {
+		return engineParams.RateTiers
+	}
+	if len(params.RateTiers) > 0 {
+		return params.RateTiers
+	}
+	return legacyRateTiers(params, engineParams)
+}
+
+// tierStarts returns the day each of an engine's tiers starts. A tier
+// triggered before the previous one starts begins on the same day, leaving
+// the previous tier no days.
+func tierStarts(params RunoutParams, engineParams EngineParams, actuals monthlyActuals) []TierSummary {
+	tiers := engineRateTiers(params, engineParams)
+	summaries := make([]TierSummary, len(tiers))
+	for i, tier := range tiers {
+		summary := TierSummary{Name: tier.Name, Rate: tier.Rate, StartDate: params.ContractStartDate, Trigger: TriggerContractStart}
+		if i > 0 {
+			summary.StartDate, summary.Trigger = tier.EffectiveDate, TriggerDate
+			if tier.Hours > 0 {
+				if start := hoursReachedOn(params, engineParams, actuals, tier.Hours).AddDays(1); summary.StartDate.IsZero() || start.Before(summary.StartDate) {
+					summary.StartDate, summary.Trigger = start, TriggerHours
+				}
+			}
+			summary.StartDate = civil.Max(summary.StartDate, summaries[i-1].StartDate)
+		}
+		summaries[i] = summary
+	}
+	return summaries
+}
+
+// hoursReachedOn returns the day an engine's TSN reaches hours, accruing
+// its actual hours and otherwise the engine's AUHours a year while it is
+// enrolled, in the contract's day-count convention. An engine enrolled at
+// the contract end keeps accruing past it. An engine already past hours
+// reaches them the day before the contract starts, and one that leaves the
+// contract before reaching them the day after it ends.
+func hoursReachedOn(params RunoutParams, engineParams EngineParams, actuals monthlyActuals, hours float64) civil.Date {
+	remaining := hours - engineParams.TSNAtContractStart
+	if remaining <= 0 {
+		return params.ContractStartDate.AddDays(-1)
+	}
+
+	// Past the contract end an enrolled engine accrues at AUHours, so a
+	// year and a day at that rate for every remaining hour is enough.
+	windows := activeWindows(params, engineParams)
+	last := params.ContractEndDate
+	if n := len(windows); n > 0 && windows[n-1].EndDate == params.ContractEndDate {
+		last = last.AddDays(int(math.Ceil(remaining/params.EngineAUHours(engineParams)*366)) + 1)
+		windows[n-1].EndDate = last
+	}
+	hoursBy := func(day civil.Date) float64 {
+		return engineFlightHours(params, engineParams, actuals, clipWindows(windows, params.ContractStartDate, day))
+	}
+	if hoursBy(last) < remaining {
+		return params.ContractEndDate.AddDays(1)
+	}
+
+	// The hours are reached during the first day by which they have been
+	// flown.
+	n := sort.Search(last.DaysSince(params.ContractStartDate)+1, func(n int) bool {
+		return hoursBy(params.ContractStartDate.AddDays(n)) >= remaining
+	})
+	return params.ContractStartDate.AddDays(n)
+}
+
+func validateRateTiers(tiers []RateTier, params RunoutParams, owner string) error {
+	for i, tier := range tiers {
+		if tier.Rate < 0 {
+			return fmt.Errorf("Rate of tier %d %scannot be negative", i+1, owner)
+		}
+		if tier.Hours < 0 {
+			return fmt.Errorf("Hours of tier %d %scannot be negative", i+1, owner)
+		}
+		if i == 0 {
+			if tier.Hours > 0 || tier.EffectiveDate.After(params.ContractStartDate) {
+				return fmt.Errorf("the first tier %sapplies from the contract start and cannot have a later EffectiveDate or Hours", owner)
+			}
+			continue
+		}
+		if tier.EffectiveDate.IsZero() && tier.Hours == 0 {
+			return fmt.Errorf("tier %d %sneeds an EffectiveDate or Hours", i+1, owner)
+		}
+		for _, previous := range tiers[1:i] {
+			if !tier.EffectiveDate.IsZero() && tier.EffectiveDate.Before(previous.EffectiveDate) {
+				return fmt.Errorf("EffectiveDate of tier %d %sis before that of an earlier tier", i+1, owner)
+			}
+			if tier.Hours > 0 && tier.Hours < previous.Hours {
+				return fmt.Errorf("Hours of tier %d %sare below those of an earlier tier", i+1, owner)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package runout
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"financialapi/internal/civil"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ActualHours is the number of hours an engine actually flew in the
+// calendar month containing Month.
+type ActualHours struct {
+	SerialNumber string     `json:"serialNumber"`
+	Month        civil.Date `json:"month"`
+	Hours        float64    `json:"hours"`
+}
+
+// UnmarshalJSON accepts a month written "YYYY-MM" as well as any date in it.
+func (a *ActualHours) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		SerialNumber string  `json:"serialNumber"`
+		Month        string  `json:"month"`
+		Hours        float64 `json:"hours"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	month, err := parseMonth(raw.Month)
+	if err != nil {
+		return err
+	}
+	*a = ActualHours{SerialNumber: raw.SerialNumber, Month: month, Hours: raw.Hours}
+	return nil
+}
+
+// ParseActualsCSV reads actual hours from CSV with a header row naming the
+// columns serialNumber, month and hours, in any order. Months are written
+// "YYYY-MM" or as any date in the month.
+func ParseActualsCSV(r io.Reader) ([]ActualHours, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid actuals CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"serialnumber", "month", "hours"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("actuals CSV has no %s column", name)
+		}
+	}
+
+	actuals := make([]ActualHours, 0, len(records)-1)
+	for i, record := range records[1:] {
+		month, err := parseMonth(strings.TrimSpace(record[columns["month"]]))
+		if err != nil {
+			return nil, fmt.Errorf("row %d of actuals CSV: %v", i+2, err)
+		}
+		hours, err := strconv.ParseFloat(strings.TrimSpace(record[columns["hours"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d of actuals CSV: invalid hours %q", i+2, record[columns["hours"]])
+		}
+		actuals = append(actuals, ActualHours{
+			SerialNumber: strings.TrimSpace(record[columns["serialnumber"]]),
+			Month:        month,
+			Hours:        hours,
+		})
+	}
+	return actuals, nil
+}
+
+// parseMonth parses "YYYY-MM" or any date, and returns the first of its month.
+func parseMonth(s string) (civil.Date, error) {
+	if len(s) == len("2006-01") {
+		s += "-01"
+	}
+	d, err := civil.Parse(s)
+	if err != nil {
+		return civil.Date{}, err
+	}
+	return civil.New(d.Year, d.Month, 1), nil
+}
+
+// monthName writes the month of d as "YYYY-MM".
+func monthName(d civil.Date) string {
+	return fmt.Sprintf("%04d-%02d", d.Year, int(d.Month))
+}
+
+// AllActuals returns Actuals together with the rows of ActualsCSV.
+func (p RunoutParams) AllActuals() ([]ActualHours, error) {
+	actuals := p.Actuals
+	if p.ActualsCSV != "" {
+		rows, err := ParseActualsCSV(strings.NewReader(p.ActualsCSV))
+		if err != nil {
+			return nil, err
+		}
+		actuals = append(append([]ActualHours(nil), actuals...), rows...)
+	}
+	return actuals, nil
+}
+
+// monthlyActuals holds, by engine serial number and first of the month, the
+// actual hours an engine flew on each day it was enrolled in the month.
+type monthlyActuals map[string]map[civil.Date]float64
+
+// actualsByEngine spreads the actual hours of each month evenly over the
+// days the engine is enrolled in it.
+func actualsByEngine(params RunoutParams, actuals []ActualHours) monthlyActuals {
+	windows := make(map[string][]ActiveWindow, len(params.EngineParams))
+	for _, ep := range params.EngineParams {
+		windows[ep.SerialNumber] = activeWindows(params, ep)
+	}
+
+	byEngine := monthlyActuals{}
+	for _, actual := range actuals {
+		month := civil.New(actual.Month.Year, actual.Month.Month, 1)
+		enrolled := 0
+		for _, w := range clipWindows(windows[actual.SerialNumber], month, month.AddDate(0, 1, -1)) {
+			enrolled += daysBetween(w.StartDate, w.EndDate)
+		}
+		if enrolled == 0 {
+			continue
+		}
+		if byEngine[actual.SerialNumber] == nil {
+			byEngine[actual.SerialNumber] = map[civil.Date]float64{}
+		}
+		byEngine[actual.SerialNumber][month] = actual.Hours / float64(enrolled)
+	}
+	return byEngine
+}
+
+func validateActuals(actuals []ActualHours, params RunoutParams) error {
+	windows := make(map[string][]ActiveWindow)
+	for _, ep := range params.EngineParams {
+		if ep.SerialNumber != "" {
+			windows[ep.SerialNumber] = activeWindows(params, ep)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, actual := range actuals {
+		enrolled, ok := windows[actual.SerialNumber]
+		if !ok {
+			return fmt.Errorf("actual hours for unknown engine %q", actual.SerialNumber)
+		}
+		month := civil.New(actual.Month.Year, actual.Month.Month, 1)
+		key := actual.SerialNumber + " " + month.String()
+		if seen[key] {
+			return fmt.Errorf("more than one actual for engine %s in %s", actual.SerialNumber, monthName(month))
+		}
+		seen[key] = true
+		if actual.Hours < 0 {
+			return fmt.Errorf("actual hours for engine %s in %s cannot be negative", actual.SerialNumber, monthName(month))
+		}
+		if len(clipWindows(enrolled, month, month.AddDate(0, 1, -1))) == 0 {
+			return fmt.Errorf("engine %s is not enrolled in %s", actual.SerialNumber, monthName(month))
+		}
+	}
+	return nil
+}
+
+// engineFlightHours returns the hours an engine flies over the windows: its
+// actual hours in months that have them, spread evenly over the days it is
+// enrolled in the month, and its AUHours a year in the day-count convention
+// otherwise.
+func engineFlightHours(params RunoutParams, engineParams EngineParams, actuals monthlyActuals, windows []ActiveWindow) float64 {
+	auHours := params.EngineAUHours(engineParams)
+	months := actuals[engineParams.SerialNumber]
+	hours := 0.0
+	for _, window := range windows {
+		if len(months) == 0 {
+			hours += auHours * params.YearFraction(window.StartDate, window.EndDate)
+			continue
+		}
+		for start := window.StartDate; !start.After(window.EndDate); {
+			month := civil.New(start.Year, start.Month, 1)
+			end := civil.Min(month.AddDate(0, 1, -1), window.EndDate)
+			if daily, ok := months[month]; ok {
+				hours += daily * float64(daysBetween(start, end))
+			} else {
+				hours += auHours * params.YearFraction(start, end)
+			}
+			start = end.AddDays(1)
+		}
+	}
+	return hours
+}
+
+// EngineTrueUp compares an engine's billed and actual flight hours and
+// flight-hour revenue up to TrueUp.ActualsThrough.
+type EngineTrueUp struct {
+	SerialNumber  string
+	BilledHours   float64
+	ActualHours   float64
+	BilledRevenue float64
+	ActualRevenue float64
+	Variance      float64
+}
+
+// TrueUp compares the flight-hour revenue billed on the forecast AUHours
+// with the revenue of the hours actually flown, up to the end of the last
+// month with actuals, and the forecast total revenue to the contract end
+// on the forecast alone. The totals of the runout itself are the forecast
+// adjusted for the actuals.
+type TrueUp struct {
+	ActualsThrough       civil.Date
+	Engines              []EngineTrueUp
+	BilledHours          float64
+	ActualHours          float64
+	BilledRevenue        float64
+	ActualRevenue        float64
+	Variance             float64
+	ForecastTotalRevenue float64
+}
+
+// calculateTrueUp compares result, calculated with actuals, with the same
+// contract calculated on the forecast alone, over the days up to the end of
+// the last month with actuals.
+func calculateTrueUp(result *RunoutResult, params RunoutParams, originalEnd civil.Date, actuals []ActualHours, byEngine monthlyActuals) error {
+	forecastParams := params
+	forecastParams.Actuals = nil
+	forecastParams.ActualsCSV = ""
+	forecastParams.IncludeMonthlySchedule = false
+	forecast, err := calculate(forecastParams, originalEnd)
+	if err != nil {
+		return err
+	}
+
+	through := civil.Date{}
+	for _, actual := range actuals {
+		through = civil.Max(through, civil.New(actual.Month.Year, actual.Month.Month, 1).AddDate(0, 1, -1))
+	}
+
+	trueUp := &TrueUp{
+		ActualsThrough:       through,
+		Engines:              make([]EngineTrueUp, len(params.EngineParams)),
+		ForecastTotalRevenue: forecast.TotalRevenue,
+	}
+	for e, ep := range params.EngineParams {
+		engine := &trueUp.Engines[e]
+		engine.SerialNumber = ep.SerialNumber
+		engine.BilledHours, engine.BilledRevenue = flownThrough(forecast, params, nil, e, through)
+		engine.ActualHours, engine.ActualRevenue = flownThrough(*result, params, byEngine, e, through)
+		engine.Variance = engine.ActualRevenue - engine.BilledRevenue
+
+		trueUp.BilledHours += engine.BilledHours
+		trueUp.ActualHours += engine.ActualHours
+		trueUp.BilledRevenue += engine.BilledRevenue
+		trueUp.ActualRevenue += engine.ActualRevenue
+	}
+	trueUp.Variance = trueUp.ActualRevenue - trueUp.BilledRevenue
+
+	result.TrueUp = trueUp
+	return nil
+}
+
+// flownThrough returns the hours engine e flies in the periods of result up
+// to through, and the flight-hour revenue they bill at its tiers there.
+func flownThrough(result RunoutResult, params RunoutParams, actuals monthlyActuals, e int, through civil.Date) (float64, float64) {
+	hours, revenue := 0.0, 0.0
+	for _, period := range result.Periods {
+		for _, tier := range period.Engines[e].Tiers {
+			flown := engineFlightHours(params, params.EngineParams[e], actuals, clipWindows(tier.windows, period.RunoutStartDate, through))
+			hours += flown
+			revenue += flown * tier.Rate * period.RateTrend
+		}
+	}
+	return hours, revenue
+}